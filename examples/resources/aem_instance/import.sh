@@ -0,0 +1,6 @@
+# Import by URL in format '<type>://[<user>@]<target>[:<port>]/<data_dir>' (query parameters are passed as client settings)
+terraform import aem_instance.author 'ssh://ec2-user@10.0.0.10:22/mnt/aemc'
+terraform import aem_instance.author 'aws-ssm://i-0123456789abcdef0/mnt/aemc?region=eu-central-1'
+
+# Import by JSON spec (credentials may be omitted if defined on the provider or host level)
+terraform import aem_instance.author '{"host": "author-1", "data_dir": "/mnt/aemc"}'
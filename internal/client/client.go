@@ -183,6 +183,18 @@ func (c Client) DirCopy(localPath string, remotePath string, override bool) erro
 }
 
 func (c Client) FileCopy(localPath string, remotePath string, override bool) error {
+	return c.fileCopy(localPath, remotePath, override, "")
+}
+
+// FileCopyMode copies the file and applies the mode (e.g. '600' for files containing secrets) before moving it into place.
+func (c Client) FileCopyMode(localPath string, remotePath string, override bool, mode string) error {
+	return c.fileCopy(localPath, remotePath, override, mode)
+}
+
+// fileCopy uploads the file to a temporary path and moves it into place.
+// If the mode is set, the file is uploaded into a private directory and the mode is applied before moving it into place,
+// so its contents are never readable by others regardless of the umask or the way the connection writes files.
+func (c Client) fileCopy(localPath string, remotePath string, override bool, mode string) error {
 	if !override {
 		exists, err := c.FileExists(remotePath)
 		if err != nil {
@@ -206,9 +218,25 @@ func (c Client) FileCopy(localPath string, remotePath string, override bool) err
 		return err
 	}
 	defer func() { _ = c.PathDelete(remoteTmpPath) }()
+	if mode != "" {
+		// created as the connection user as the upload is not elevated
+		uploader := c
+		uploader.Sudo = false
+		remoteTmpDir := remoteTmpPath
+		if _, err := uploader.RunShellPurely(fmt.Sprintf("mkdir -m 700 %s", remoteTmpDir)); err != nil {
+			return fmt.Errorf("cannot create private temporary directory '%s': %w", remoteTmpDir, err)
+		}
+		defer func() { _ = c.PathDelete(remoteTmpDir) }()
+		remoteTmpPath = fmt.Sprintf("%s/%s", remoteTmpDir, filepath.Base(remotePath))
+	}
 	if err := c.connection.CopyFile(localPath, remoteTmpPath); err != nil {
 		return err
 	}
+	if mode != "" {
+		if _, err := c.RunShellPurely(fmt.Sprintf("chmod %s %s", mode, remoteTmpPath)); err != nil {
+			return fmt.Errorf("cannot set mode of file '%s': %w", remotePath, err)
+		}
+	}
 	if err := c.FileMove(remoteTmpPath, remotePath); err != nil {
 		return err
 	}
@@ -234,6 +262,11 @@ func (c Client) PathDelete(path string) error {
 }
 
 func (c Client) FileWrite(remotePath string, text string) error {
+	return c.FileWriteMode(remotePath, text, "")
+}
+
+// FileWriteMode writes the text to the remote file with the given mode (e.g. '600' for files containing secrets).
+func (c Client) FileWriteMode(remotePath string, text string, mode string) error {
 	file, err := os.CreateTemp(os.TempDir(), "tf-provider-aem-*.tmp")
 	path := file.Name()
 	defer func() { _ = file.Close(); _ = os.Remove(path) }()
@@ -243,8 +276,16 @@ func (c Client) FileWrite(remotePath string, text string) error {
 	if _, err := file.WriteString(text); err != nil {
 		return fmt.Errorf("cannot write text to local temporary file to be copied to remote path '%s': %w", remotePath, err)
 	}
-	if err := c.FileCopy(path, remotePath, true); err != nil {
+	if err := c.fileCopy(path, remotePath, true, mode); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (c Client) FileRead(remotePath string) (string, error) {
+	out, err := c.RunShellPurely(fmt.Sprintf("cat %s", remotePath))
+	if err != nil {
+		return "", fmt.Errorf("cannot read file '%s': %w", remotePath, err)
+	}
+	return string(out), nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/spf13/cast"
+	"golang.org/x/time/rate"
+	"strings"
+	"sync"
 )
 
-func (c ClientManager) Make(typeName string, settings map[string]string) (*Client, error) {
+func (c *ClientManager) Make(typeName string, settings map[string]string) (*Client, error) {
 	connection, err := c.connection(typeName, settings)
 	if err != nil {
 		return nil, err
@@ -20,7 +23,7 @@ func (c ClientManager) Make(typeName string, settings map[string]string) (*Clien
 	}, nil
 }
 
-func (c ClientManager) Use(typeName string, settings map[string]string, callback func(c Client) error) error {
+func (c *ClientManager) Use(typeName string, settings map[string]string, callback func(c Client) error) error {
 	client, err := c.Make(typeName, settings)
 	if err != nil {
 		return err
@@ -28,7 +31,7 @@ func (c ClientManager) Use(typeName string, settings map[string]string, callback
 	return client.Use(callback)
 }
 
-func (c ClientManager) connection(typeName string, settings map[string]string) (Connection, error) {
+func (c *ClientManager) connection(typeName string, settings map[string]string) (Connection, error) {
 	switch typeName {
 	case "ssh":
 		return &SSHConnection{
@@ -38,20 +41,127 @@ func (c ClientManager) connection(typeName string, settings map[string]string) (
 			privateKeyPassphrase: settings["private_key_passphrase"],
 			port:                 cast.ToInt(settings["port"]),
 			secure:               cast.ToBool(settings["secure"]),
+			hostKey:              settings["host_key"],
 		}, nil
 	case "aws-ssm":
 		return &AWSSSMConnection{
 			instanceID:           settings["instance_id"],
-			region:               settings["region"],
+			instanceName:         settings["instance_name"],
+			instanceTags:         settingsWithPrefix(settings, awsInstanceTagPrefix),
+			awsConfig:            c.awsConfig(settings),
+			s3Bucket:             settings["s3_bucket"],
+			s3KeyPrefix:          settings["s3_key_prefix"],
+			documentName:         settings["document_name"],
+			executionTimeout:     cast.ToDuration(settings["execution_timeout"]),
+			workingDirectory:     settings["working_directory"],
+			runAsUser:            settings["run_as_user"],
 			context:              context.Background(),
 			commandOutputTimeout: cast.ToDuration(settings["command_output_timeout"]),
 			commandWaitMin:       cast.ToDuration(settings["command_wait_min"]),
 			commandWaitMax:       cast.ToDuration(settings["command_wait_max"]),
 		}, nil
+	case "aws-eic":
+		return &AWSEICConnection{
+			instanceID:        settings["instance_id"],
+			user:              settings["user"],
+			host:              settings["host"],
+			port:              cast.ToInt(settings["port"]),
+			publicIP:          cast.ToBool(settings["public_ip"]),
+			secure:            cast.ToBool(settings["secure"]),
+			hostKey:           settings["host_key"],
+			endpointID:        settings["endpoint_id"],
+			maxTunnelDuration: cast.ToDuration(settings["max_tunnel_duration"]),
+			awsConfig:         c.awsConfig(settings),
+			context:           context.Background(),
+		}, nil
 	}
 	return nil, fmt.Errorf("unknown AEM client type: %s", typeName)
 }
 
-type ClientManager struct{}
+// awsConfig creates AWS settings with the API rate limiter shared by all connections made by this manager.
+func (c *ClientManager) awsConfig(settings map[string]string) AWSConfig {
+	config := newAWSConfig(settings)
+	if c.awsAPIRateLimit <= 0 {
+		return config
+	}
+	burst := c.awsAPIBurst
+	if burst <= 0 {
+		burst = 2 * int(c.awsAPIRateLimit+0.5)
+	}
+
+	c.awsLimitersMutex.Lock()
+	defer c.awsLimitersMutex.Unlock()
+
+	key := config.limiterKey()
+	limiter, ok := c.awsLimiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(c.awsAPIRateLimit), burst)
+		c.awsLimiters[key] = limiter
+	}
+	config.apiLimiter = limiter
+	return config
+}
 
-var ClientManagerDefault = &ClientManager{}
+func settingsWithPrefix(settings map[string]string, prefix string) map[string]string {
+	result := map[string]string{}
+	for name, value := range settings {
+		if strings.HasPrefix(name, prefix) {
+			result[strings.TrimPrefix(name, prefix)] = value
+		}
+	}
+	return result
+}
+
+// Host is a named machine from the inventory with connection details and arbitrary facts about it.
+// Unknown hosts are the ones whose details are not known yet when planning (e.g. refer to machines not created yet).
+type Host struct {
+	TypeName    string
+	Settings    map[string]string
+	Credentials map[string]string
+	Facts       map[string]string
+	Unknown     bool
+}
+
+func (c *ClientManager) RegisterHost(name string, host Host) {
+	c.hosts[name] = host
+}
+
+// SetHostsUnknown marks the whole inventory as not known yet, so that any host name is accepted when planning.
+func (c *ClientManager) SetHostsUnknown() {
+	c.hostsUnknown = true
+}
+
+func (c *ClientManager) Host(name string) (*Host, error) {
+	host, ok := c.hosts[name]
+	if !ok {
+		if c.hostsUnknown {
+			return &Host{Unknown: true}, nil
+		}
+		return nil, fmt.Errorf("host '%s' is not defined in the inventory", name)
+	}
+	return &host, nil
+}
+
+type ClientManager struct {
+	hosts            map[string]Host
+	hostsUnknown     bool
+	awsAPIRateLimit  float64
+	awsAPIBurst      int
+	awsLimiters      map[string]*rate.Limiter
+	awsLimitersMutex sync.Mutex
+}
+
+func NewClientManager() *ClientManager {
+	return &ClientManager{
+		hosts:           map[string]Host{},
+		awsAPIRateLimit: AWSAPIRateLimitDefault,
+		awsLimiters:     map[string]*rate.Limiter{},
+	}
+}
+
+// SetAWSAPIRateLimit configures the rate (requests per second) and burst of AWS API calls made by all connections sharing the same AWS account, region and credentials.
+// Rate limit 0 disables limiting; burst 0 means twice the rate limit.
+func (c *ClientManager) SetAWSAPIRateLimit(rateLimit float64, burst int) {
+	c.awsAPIRateLimit = rateLimit
+	c.awsAPIBurst = burst
+}
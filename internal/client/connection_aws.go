@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/spf13/cast"
+	"golang.org/x/time/rate"
+	"strings"
+	"time"
+)
+
+const awsEndpointURLPrefix = "endpoint_url_"
+
+// AWSAPIRateLimitDefault is the default rate (requests per second) of AWS API calls shared by connections to the same AWS account and region.
+const AWSAPIRateLimitDefault = 5.0
+
+// AWSConfig holds settings shared by all AWS-based connection types (credentials, role assumption, endpoints).
+type AWSConfig struct {
+	region                 string
+	profile                string
+	sharedConfigFiles      []string
+	sharedCredentialsFiles []string
+
+	accessKey    string
+	secretKey    string
+	sessionToken string
+
+	assumeRoleARN         string
+	assumeRoleExternalID  string
+	assumeRoleSessionName string
+	assumeRoleDuration    time.Duration
+
+	webIdentityRoleARN     string
+	webIdentityTokenFile   string
+	webIdentitySessionName string
+
+	endpointURL  string
+	endpointURLs map[string]string
+
+	retryMode        string
+	retryMaxAttempts int
+	retryMaxBackoff  time.Duration
+	apiLimiter       *rate.Limiter
+}
+
+func newAWSConfig(settings map[string]string) AWSConfig {
+	endpointURLs := settingsWithPrefix(settings, awsEndpointURLPrefix)
+	return AWSConfig{
+		region:                 settings["region"],
+		profile:                settings["profile"],
+		sharedConfigFiles:      splitList(settings["shared_config_files"]),
+		sharedCredentialsFiles: splitList(settings["shared_credentials_files"]),
+
+		accessKey:    settings["access_key"],
+		secretKey:    settings["secret_key"],
+		sessionToken: settings["session_token"],
+
+		assumeRoleARN:         settings["assume_role_arn"],
+		assumeRoleExternalID:  settings["assume_role_external_id"],
+		assumeRoleSessionName: settings["assume_role_session_name"],
+		assumeRoleDuration:    cast.ToDuration(settings["assume_role_duration"]),
+
+		webIdentityRoleARN:     settings["web_identity_role_arn"],
+		webIdentityTokenFile:   settings["web_identity_token_file"],
+		webIdentitySessionName: settings["web_identity_session_name"],
+
+		endpointURL:  settings["endpoint_url"],
+		endpointURLs: endpointURLs,
+
+		retryMode:        settings["retry_mode"],
+		retryMaxAttempts: cast.ToInt(settings["retry_max_attempts"]),
+		retryMaxBackoff:  cast.ToDuration(settings["retry_max_backoff"]),
+	}
+}
+
+// limiterKey identifies the API rate limit bucket per credential configuration; connections sharing the same region, profile, access key and assumed roles share the limiter.
+// The account behind the credentials is not resolved, so differently configured credentials of the same account are limited separately.
+func (c AWSConfig) limiterKey() string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s", c.region, c.profile, c.accessKey, c.assumeRoleARN, c.webIdentityRoleARN)
+}
+
+func (c AWSConfig) Info() string {
+	region := c.region
+	if region == "" {
+		region = "<default>"
+	}
+	if c.profile != "" {
+		return fmt.Sprintf("region='%s', profile='%s'", region, c.profile)
+	}
+	return fmt.Sprintf("region='%s'", region)
+}
+
+func (c AWSConfig) Load(ctx context.Context) (aws.Config, error) {
+	var optFns []func(*config.LoadOptions) error
+	if c.region != "" {
+		optFns = append(optFns, config.WithRegion(c.region))
+	}
+	if c.profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(c.profile))
+	}
+	if len(c.sharedConfigFiles) > 0 {
+		optFns = append(optFns, config.WithSharedConfigFiles(c.sharedConfigFiles))
+	}
+	if len(c.sharedCredentialsFiles) > 0 {
+		optFns = append(optFns, config.WithSharedCredentialsFiles(c.sharedCredentialsFiles))
+	}
+	if c.accessKey != "" || c.secretKey != "" {
+		if c.accessKey == "" || c.secretKey == "" {
+			return aws.Config{}, fmt.Errorf("aws: both access key and secret key are required when using static credentials")
+		}
+		optFns = append(optFns, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(c.accessKey, c.secretKey, c.sessionToken)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return cfg, fmt.Errorf("aws: cannot load config: %w", err)
+	}
+	if c.endpointURL != "" {
+		cfg.BaseEndpoint = aws.String(c.endpointURL)
+	}
+	retryer, err := c.retryer()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Retryer = retryer
+	if c.apiLimiter != nil {
+		cfg.APIOptions = append(cfg.APIOptions, awsRateLimitMiddleware(c.apiLimiter))
+	}
+
+	if c.webIdentityRoleARN != "" {
+		if c.webIdentityTokenFile == "" {
+			return cfg, fmt.Errorf("aws: web identity token file is required when assuming role '%s' with web identity", c.webIdentityRoleARN)
+		}
+		provider := stscreds.NewWebIdentityRoleProvider(c.stsClient(cfg), c.webIdentityRoleARN, stscreds.IdentityTokenFile(c.webIdentityTokenFile), func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = c.webIdentitySessionName
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	if c.assumeRoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(c.stsClient(cfg), c.assumeRoleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = c.assumeRoleSessionName
+			o.Duration = c.assumeRoleDuration
+			if c.assumeRoleExternalID != "" {
+				o.ExternalID = aws.String(c.assumeRoleExternalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+	return cfg, nil
+}
+
+// Endpoint returns the custom endpoint URL for a specific service (e.g. 'ssm', 's3') or nil to use the default one.
+func (c AWSConfig) Endpoint(service string) *string {
+	if url, ok := c.endpointURLs[service]; ok && url != "" {
+		return aws.String(url)
+	}
+	return nil
+}
+
+func (c AWSConfig) retryer() (func() aws.Retryer, error) {
+	maxAttempts := c.retryMaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 10
+	}
+	maxBackoff := c.retryMaxBackoff
+	if maxBackoff == 0 {
+		maxBackoff = 30 * time.Second
+	}
+	standardOptions := func(o *retry.StandardOptions) {
+		o.MaxAttempts = maxAttempts
+		o.MaxBackoff = maxBackoff
+	}
+	switch c.retryMode {
+	case "", "adaptive":
+		return func() aws.Retryer {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, standardOptions)
+			})
+		}, nil
+	case "standard":
+		return func() aws.Retryer {
+			return retry.NewStandard(standardOptions)
+		}, nil
+	}
+	return nil, fmt.Errorf("aws: unknown retry mode '%s' (expected 'adaptive' or 'standard')", c.retryMode)
+}
+
+// awsRateLimitMiddleware delays each API call attempt (including retries) until the shared limiter allows it.
+func awsRateLimitMiddleware(limiter *rate.Limiter) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("AEMRateLimit", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if err := limiter.Wait(ctx); err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, fmt.Errorf("aws: API rate limit wait interrupted: %w", err)
+			}
+			return next.HandleFinalize(ctx, in)
+		}), middleware.After)
+	}
+}
+
+func (c AWSConfig) stsClient(cfg aws.Config) *sts.Client {
+	return sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if endpoint := c.Endpoint("sts"); endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+}
+
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
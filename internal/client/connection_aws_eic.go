@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/websocket"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AWSEICConnection pushes a one-time SSH public key using EC2 Instance Connect, then connects over SSH directly or through an EC2 Instance Connect Endpoint.
+type AWSEICConnection struct {
+	ssh *SSHConnection
+
+	instanceID        string
+	user              string
+	host              string
+	port              int
+	publicIP          bool
+	secure            bool
+	hostKey           string
+	endpointID        string
+	maxTunnelDuration time.Duration
+	awsConfig         AWSConfig
+	context           context.Context
+}
+
+func (a *AWSEICConnection) Info() string {
+	if a.endpointID != "" {
+		return fmt.Sprintf("eic: instance_id='%s', user='%s', endpoint_id='%s', %s", a.instanceID, a.user, a.endpointID, a.awsConfig.Info())
+	}
+	return fmt.Sprintf("eic: instance_id='%s', user='%s', %s", a.instanceID, a.user, a.awsConfig.Info())
+}
+
+func (a *AWSEICConnection) User() string {
+	return a.user
+}
+
+func (a *AWSEICConnection) Connect() error {
+	if a.instanceID == "" {
+		return fmt.Errorf("eic: instance ID is required")
+	}
+	if a.user == "" {
+		a.user = "ec2-user"
+	}
+	if a.port == 0 {
+		a.port = 22
+	}
+	if a.maxTunnelDuration == 0 {
+		a.maxTunnelDuration = time.Hour
+	}
+	if a.secure && a.hostKey == "" {
+		return fmt.Errorf("eic: host key is required to verify the instance as the client key is generated for each connection")
+	}
+
+	cfg, err := a.awsConfig.Load(a.context)
+	if err != nil {
+		return err
+	}
+	ec2Client := ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+		if endpoint := a.awsConfig.Endpoint("ec2"); endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+	eicClient := ec2instanceconnect.NewFromConfig(cfg, func(o *ec2instanceconnect.Options) {
+		if endpoint := a.awsConfig.Endpoint("ec2_instance_connect"); endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+
+	host, err := a.resolveHost(ec2Client)
+	if err != nil {
+		return err
+	}
+	signer, err := a.sendPublicKey(eicClient)
+	if err != nil {
+		return err
+	}
+	a.ssh = &SSHConnection{
+		host:    host,
+		user:    a.user,
+		port:    a.port,
+		secure:  a.secure,
+		hostKey: a.hostKey,
+		signer:  signer,
+	}
+	if a.endpointID != "" {
+		tunnelURL, err := a.tunnelURL(ec2Client, cfg, host)
+		if err != nil {
+			return err
+		}
+		a.ssh.dial = func() (net.Conn, error) { return a.openTunnel(tunnelURL) }
+	}
+	return a.ssh.Connect()
+}
+
+func (a *AWSEICConnection) resolveHost(client *ec2.Client) (string, error) {
+	if a.host != "" {
+		return a.host, nil
+	}
+	out, err := client.DescribeInstances(a.context, &ec2.DescribeInstancesInput{InstanceIds: []string{a.instanceID}})
+	if err != nil {
+		return "", fmt.Errorf("eic: cannot describe instance '%s': %w", a.instanceID, err)
+	}
+	for _, reservation := range out.Reservations {
+		for _, instance := range reservation.Instances {
+			if a.publicIP && a.endpointID == "" {
+				if instance.PublicIpAddress == nil {
+					return "", fmt.Errorf("eic: instance '%s' has no public IP address", a.instanceID)
+				}
+				return aws.ToString(instance.PublicIpAddress), nil
+			}
+			if instance.PrivateIpAddress == nil {
+				return "", fmt.Errorf("eic: instance '%s' has no private IP address", a.instanceID)
+			}
+			return aws.ToString(instance.PrivateIpAddress), nil
+		}
+	}
+	return "", fmt.Errorf("eic: instance '%s' not found", a.instanceID)
+}
+
+// sendPublicKey generates an ephemeral key pair and pushes its public key which remains valid for 60 seconds.
+func (a *AWSEICConnection) sendPublicKey(client *ec2instanceconnect.Client) (ssh.Signer, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("eic: cannot generate SSH key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("eic: cannot create SSH signer: %w", err)
+	}
+	_, err = client.SendSSHPublicKey(a.context, &ec2instanceconnect.SendSSHPublicKeyInput{
+		InstanceId:     aws.String(a.instanceID),
+		InstanceOSUser: aws.String(a.user),
+		SSHPublicKey:   aws.String(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eic: cannot send SSH public key to instance '%s': %w", a.instanceID, err)
+	}
+	return signer, nil
+}
+
+// tunnelURL presigns the WebSocket URL of the EC2 Instance Connect Endpoint tunnel leading to the instance SSH port.
+func (a *AWSEICConnection) tunnelURL(client *ec2.Client, cfg aws.Config, host string) (string, error) {
+	out, err := client.DescribeInstanceConnectEndpoints(a.context, &ec2.DescribeInstanceConnectEndpointsInput{
+		InstanceConnectEndpointIds: []string{a.endpointID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("eic: cannot describe instance connect endpoint '%s': %w", a.endpointID, err)
+	}
+	if len(out.InstanceConnectEndpoints) == 0 {
+		return "", fmt.Errorf("eic: instance connect endpoint '%s' not found", a.endpointID)
+	}
+	dnsName := aws.ToString(out.InstanceConnectEndpoints[0].DnsName)
+
+	query := url.Values{}
+	query.Set("instanceConnectEndpointId", a.endpointID)
+	query.Set("remotePort", fmt.Sprint(a.port))
+	query.Set("privateIpAddress", host)
+	query.Set("maxTunnelDuration", fmt.Sprint(int(a.maxTunnelDuration.Seconds())))
+	query.Set("X-Amz-Expires", "60")
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("wss://%s/openTunnel?%s", dnsName, query.Encode()), nil)
+	if err != nil {
+		return "", fmt.Errorf("eic: cannot create tunnel request: %w", err)
+	}
+	creds, err := cfg.Credentials.Retrieve(a.context)
+	if err != nil {
+		return "", fmt.Errorf("eic: cannot retrieve AWS credentials: %w", err)
+	}
+	const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signedURL, _, err := v4.NewSigner().PresignHTTP(a.context, creds, req, emptyPayloadHash, "ec2-instance-connect", cfg.Region, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("eic: cannot sign tunnel request: %w", err)
+	}
+	return signedURL, nil
+}
+
+func (a *AWSEICConnection) openTunnel(tunnelURL string) (net.Conn, error) {
+	location, err := url.Parse(tunnelURL)
+	if err != nil {
+		return nil, fmt.Errorf("eic: cannot parse tunnel URL: %w", err)
+	}
+	config, err := websocket.NewConfig(tunnelURL, fmt.Sprintf("https://%s", location.Host))
+	if err != nil {
+		return nil, fmt.Errorf("eic: cannot configure tunnel: %w", err)
+	}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("eic: cannot open tunnel through endpoint '%s': %w", a.endpointID, err)
+	}
+	conn.PayloadType = websocket.BinaryFrame
+	return conn, nil
+}
+
+func (a *AWSEICConnection) Disconnect() error {
+	if a.ssh == nil {
+		return nil
+	}
+	return a.ssh.Disconnect()
+}
+
+func (a *AWSEICConnection) Command(cmdLine []string) ([]byte, error) {
+	return a.ssh.Command(cmdLine)
+}
+
+func (a *AWSEICConnection) CopyFile(localPath string, remotePath string) error {
+	return a.ssh.CopyFile(localPath, remotePath)
+}
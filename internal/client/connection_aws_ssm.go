@@ -5,18 +5,35 @@ import (
 	"encoding/base64"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
+const (
+	awsInstanceTagPrefix    = "tag:"
+	awsSSMCopyChunkSize     = 48 * 1024
+	awsSSMCopyInlineMaxSize = 1024 * 1024
+)
+
 type AWSSSMConnection struct {
 	instanceID           string
-	region               string
+	instanceName         string
+	instanceTags         map[string]string
+	awsConfig            AWSConfig
 	client               *ssm.Client
-	sessionId            *string
+	s3Client             *s3.Client
+	s3Bucket             string
+	s3KeyPrefix          string
+	documentName         string
+	executionTimeout     time.Duration
+	workingDirectory     string
+	runAsUser            string
 	context              context.Context
 	commandOutputTimeout time.Duration
 	commandWaitMax       time.Duration
@@ -24,11 +41,10 @@ type AWSSSMConnection struct {
 }
 
 func (a *AWSSSMConnection) Info() string {
-	region := a.region
-	if region == "" {
-		region = "<default>"
+	if a.instanceID == "" {
+		return fmt.Sprintf("ssm: instance_name='%s', instance_tags='%v', %s", a.instanceName, a.instanceTags, a.awsConfig.Info())
 	}
-	return fmt.Sprintf("ssm: instance_id='%s', region='%s'", a.instanceID, region)
+	return fmt.Sprintf("ssm: instance_id='%s', %s", a.instanceID, a.awsConfig.Info())
 }
 
 func (a *AWSSSMConnection) User() string {
@@ -50,47 +66,149 @@ func (a *AWSSSMConnection) Connect() error {
 		a.commandWaitMax = 5 * time.Second
 	}
 
-	var optFns []func(*config.LoadOptions) error
-	if a.region != "" {
-		optFns = append(optFns, config.WithRegion(a.region))
+	if a.documentName == "" {
+		a.documentName = "AWS-RunShellScript"
+	}
+	if a.s3KeyPrefix == "" {
+		a.s3KeyPrefix = "terraform-provider-aem"
 	}
 
-	cfg, err := config.LoadDefaultConfig(a.context, optFns...)
+	cfg, err := a.awsConfig.Load(a.context)
 	if err != nil {
 		return err
 	}
 
-	client := ssm.NewFromConfig(cfg)
-	sessionIn := &ssm.StartSessionInput{Target: aws.String(a.instanceID)}
-	sessionOut, err := client.StartSession(a.context, sessionIn)
-	if err != nil {
-		return fmt.Errorf("ssm: error starting session: %v", err)
+	client := ssm.NewFromConfig(cfg, func(o *ssm.Options) {
+		if endpoint := a.awsConfig.Endpoint("ssm"); endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+	if a.s3Bucket != "" {
+		a.s3Client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+			if endpoint := a.awsConfig.Endpoint("s3"); endpoint != nil {
+				o.BaseEndpoint = endpoint
+				o.UsePathStyle = true
+			}
+		})
+	}
+	if err := a.resolveInstanceID(client); err != nil {
+		return err
+	}
+	if err := a.checkAgentOnline(client); err != nil {
+		return err
 	}
 
 	a.client = client
-	a.sessionId = sessionOut.SessionId
 
 	return nil
 }
 
-func (a *AWSSSMConnection) Disconnect() error {
-	sessionIn := &ssm.TerminateSessionInput{SessionId: a.sessionId}
-	_, err := a.client.TerminateSession(a.context, sessionIn)
+// resolveInstanceID determines the target managed node (EC2 instance 'i-*' or hybrid activation 'mi-*') by ID, name or tags.
+func (a *AWSSSMConnection) resolveInstanceID(client *ssm.Client) error {
+	if a.instanceID != "" {
+		if !strings.HasPrefix(a.instanceID, "i-") && !strings.HasPrefix(a.instanceID, "mi-") {
+			return fmt.Errorf("ssm: instance ID '%s' is invalid (expected EC2 instance 'i-*' or hybrid managed node 'mi-*')", a.instanceID)
+		}
+		return nil
+	}
+	if a.instanceName == "" && len(a.instanceTags) == 0 {
+		return fmt.Errorf("ssm: instance ID, name or tags are required")
+	}
+	tagFilters := []ssmtypes.InstanceInformationStringFilter{
+		{Key: aws.String("PingStatus"), Values: []string{string(ssmtypes.PingStatusOnline)}},
+	}
+	for name, value := range a.instanceTags {
+		tagFilters = append(tagFilters, ssmtypes.InstanceInformationStringFilter{Key: aws.String(awsInstanceTagPrefix + name), Values: []string{value}})
+	}
+	var ids []string
+	var err error
+	if a.instanceName != "" {
+		nameFilter := ssmtypes.InstanceInformationStringFilter{Key: aws.String(awsInstanceTagPrefix + "Name"), Values: []string{a.instanceName}}
+		ids, err = a.findInstanceIDs(client, append(tagFilters, nameFilter), func(info ssmtypes.InstanceInformation) bool { return true })
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 { // hybrid managed nodes are named upon activation, not by tag
+			ids, err = a.findInstanceIDs(client, tagFilters, func(info ssmtypes.InstanceInformation) bool {
+				return info.ResourceType == ssmtypes.ResourceTypeManagedInstance && aws.ToString(info.Name) == a.instanceName
+			})
+		}
+	} else {
+		ids, err = a.findInstanceIDs(client, tagFilters, func(info ssmtypes.InstanceInformation) bool { return true })
+	}
+	if err != nil {
+		return err
+	}
+	switch len(ids) {
+	case 0:
+		return fmt.Errorf("ssm: no online managed node found matching name '%s' and tags '%v'", a.instanceName, a.instanceTags)
+	case 1:
+		a.instanceID = ids[0]
+		return nil
+	}
+	return fmt.Errorf("ssm: multiple managed nodes found matching name '%s' and tags '%v': %s", a.instanceName, a.instanceTags, strings.Join(ids, ", "))
+}
+
+func (a *AWSSSMConnection) findInstanceIDs(client *ssm.Client, filters []ssmtypes.InstanceInformationStringFilter, matcher func(info ssmtypes.InstanceInformation) bool) ([]string, error) {
+	var ids []string
+	paginator := ssm.NewDescribeInstanceInformationPaginator(client, &ssm.DescribeInstanceInformationInput{Filters: filters})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(a.context)
+		if err != nil {
+			return nil, fmt.Errorf("ssm: error looking up managed nodes: %v", err)
+		}
+		for _, info := range page.InstanceInformationList {
+			if matcher(info) {
+				ids = append(ids, aws.ToString(info.InstanceId))
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (a *AWSSSMConnection) checkAgentOnline(client *ssm.Client) error {
+	infoIn := &ssm.DescribeInstanceInformationInput{
+		Filters: []ssmtypes.InstanceInformationStringFilter{
+			{Key: aws.String("InstanceIds"), Values: []string{a.instanceID}},
+		},
+	}
+	infoOut, err := client.DescribeInstanceInformation(a.context, infoIn)
 	if err != nil {
-		return fmt.Errorf("ssm: error terminating session: %v", err)
+		return fmt.Errorf("ssm: error describing instance '%s': %v", a.instanceID, err)
+	}
+	if len(infoOut.InstanceInformationList) == 0 {
+		return fmt.Errorf("ssm: instance '%s' is not registered as managed node", a.instanceID)
 	}
+	pingStatus := infoOut.InstanceInformationList[0].PingStatus
+	if pingStatus != ssmtypes.PingStatusOnline {
+		return fmt.Errorf("ssm: agent on instance '%s' is not online (status '%s')", a.instanceID, pingStatus)
+	}
+	return nil
+}
 
+func (a *AWSSSMConnection) Disconnect() error {
+	// commands are sent independently, so there is no session to terminate
 	return nil
 }
 
 func (a *AWSSSMConnection) Command(cmdLine []string) ([]byte, error) {
 	command := strings.Join(cmdLine, " ")
+	if a.runAsUser != "" {
+		command = fmt.Sprintf("sudo -n -H -u %s -- sh -c '%s'", a.runAsUser, strings.ReplaceAll(command, "'", `'\''`))
+	}
+	parameters := map[string][]string{
+		"commands": {command},
+	}
+	if a.workingDirectory != "" {
+		parameters["workingDirectory"] = []string{a.workingDirectory}
+	}
+	if a.executionTimeout > 0 {
+		parameters["executionTimeout"] = []string{fmt.Sprintf("%d", int(a.executionTimeout.Seconds()))}
+	}
 	commandIn := &ssm.SendCommandInput{
-		DocumentName: aws.String("AWS-RunShellScript"),
+		DocumentName: aws.String(a.documentName),
 		InstanceIds:  []string{a.instanceID},
-		Parameters: map[string][]string{
-			"commands": {command},
-		},
+		Parameters:   parameters,
 	}
 	runOut, err := a.client.SendCommand(a.context, commandIn)
 	if err != nil {
@@ -125,13 +243,59 @@ func (a *AWSSSMConnection) Command(cmdLine []string) ([]byte, error) {
 }
 
 func (a *AWSSSMConnection) CopyFile(localPath string, remotePath string) error {
+	if a.s3Client != nil {
+		return a.copyFileViaS3(localPath, remotePath)
+	}
 	fileContent, err := os.ReadFile(localPath)
 	if err != nil {
 		return fmt.Errorf("ssm: error reading local file: %v", err)
 	}
-	encodedContent := base64.StdEncoding.EncodeToString(fileContent)
+	// each chunk is a separate SSM command, so bigger files would take too many calls
+	if len(fileContent) > awsSSMCopyInlineMaxSize {
+		return fmt.Errorf("ssm: file '%s' is too large to be copied inline (%d bytes, limit is %d bytes); set 's3_bucket' to stage it in S3 or download it on the machine", localPath, len(fileContent), awsSSMCopyInlineMaxSize)
+	}
+	// larger files (e.g. scripts with embedded data) are sent in chunks to stay within the maximum size of the SSM command
+	redirect := ">"
+	for offset := 0; offset == 0 || offset < len(fileContent); offset += awsSSMCopyChunkSize {
+		end := offset + awsSSMCopyChunkSize
+		if end > len(fileContent) {
+			end = len(fileContent)
+		}
+		encodedContent := base64.StdEncoding.EncodeToString(fileContent[offset:end])
+		cmd := fmt.Sprintf("echo -n %s | base64 -d %s %s", encodedContent, redirect, remotePath)
+		if _, err = a.Command([]string{cmd}); err != nil {
+			return err
+		}
+		redirect = ">>"
+	}
+	return nil
+}
+
+// copyFileViaS3 stages the file in the S3 bucket as passing it inline is limited by the maximum size of the SSM command.
+func (a *AWSSSMConnection) copyFileViaS3(localPath string, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("ssm: error reading local file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
 
-	cmd := fmt.Sprintf("echo -n %s | base64 -d > %s", encodedContent, remotePath)
+	key := path.Join(a.s3KeyPrefix, a.instanceID, fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(remotePath)))
+	putIn := &s3.PutObjectInput{Bucket: aws.String(a.s3Bucket), Key: aws.String(key), Body: file}
+	if _, err := a.s3Client.PutObject(a.context, putIn); err != nil {
+		return fmt.Errorf("ssm: error uploading file to bucket '%s': %v", a.s3Bucket, err)
+	}
+	defer func() {
+		deleteIn := &s3.DeleteObjectInput{Bucket: aws.String(a.s3Bucket), Key: aws.String(key)}
+		_, _ = a.s3Client.DeleteObject(a.context, deleteIn)
+	}()
+
+	cmd := fmt.Sprintf("aws s3 cp --no-progress 's3://%s/%s' '%s'", a.s3Bucket, key, remotePath)
+	if endpoint := a.awsConfig.Endpoint("s3"); endpoint != nil {
+		cmd += fmt.Sprintf(" --endpoint-url '%s'", *endpoint)
+	}
+	if a.awsConfig.region != "" {
+		cmd += fmt.Sprintf(" --region '%s'", a.awsConfig.region)
+	}
 	_, err = a.Command([]string{cmd})
 	return err
 }
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// testManagedNode is a managed node served by the fake SSM API, matched against 'PingStatus' and 'tag:*' filters.
+type testManagedNode struct {
+	id           string
+	name         string
+	resourceType string
+	pingStatus   string
+	tags         map[string]string
+}
+
+func testSSMClient(t *testing.T, nodes []testManagedNode) *ssm.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Filters []struct {
+				Key    string
+				Values []string
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var list []map[string]string
+		for _, node := range nodes {
+			matches := true
+			for _, filter := range input.Filters {
+				var value string
+				switch {
+				case filter.Key == "PingStatus":
+					value = node.pingStatus
+				case strings.HasPrefix(filter.Key, awsInstanceTagPrefix):
+					value = node.tags[strings.TrimPrefix(filter.Key, awsInstanceTagPrefix)]
+				}
+				if len(filter.Values) == 0 || filter.Values[0] != value {
+					matches = false
+				}
+			}
+			if matches {
+				list = append(list, map[string]string{"InstanceId": node.id, "Name": node.name, "ResourceType": node.resourceType, "PingStatus": node.pingStatus})
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(map[string]any{"InstanceInformationList": list})
+	}))
+	t.Cleanup(server.Close)
+	return ssm.New(ssm.Options{
+		Region:           "eu-central-1",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+func TestAWSSSMResolveInstanceID(t *testing.T) {
+	nodes := []testManagedNode{
+		{id: "i-0000000000000001", resourceType: "EC2Instance", pingStatus: "Online", tags: map[string]string{"Name": "aem-author", "Env": "dev"}},
+		{id: "i-0000000000000002", resourceType: "EC2Instance", pingStatus: "Online", tags: map[string]string{"Name": "aem-publish", "Env": "dev"}},
+		{id: "i-0000000000000003", resourceType: "EC2Instance", pingStatus: "ConnectionLost", tags: map[string]string{"Name": "aem-preview", "Env": "dev"}},
+		{id: "mi-0000000000000004", name: "aem-onprem", resourceType: "ManagedInstance", pingStatus: "Online", tags: map[string]string{"Env": "prod"}},
+	}
+	tests := []struct {
+		name         string
+		instanceID   string
+		instanceName string
+		instanceTags map[string]string
+		wantID       string
+		wantError    string
+	}{
+		{name: "EC2 instance ID", instanceID: "i-0123456789abcdef0", wantID: "i-0123456789abcdef0"},
+		{name: "hybrid managed node ID", instanceID: "mi-0123456789abcdef0", wantID: "mi-0123456789abcdef0"},
+		{name: "invalid ID", instanceID: "vm-0123", wantError: "is invalid"},
+		{name: "nothing to look up by", wantError: "instance ID, name or tags are required"},
+		{name: "EC2 instance by name tag", instanceName: "aem-author", wantID: "i-0000000000000001"},
+		{name: "hybrid managed node by activation name", instanceName: "aem-onprem", wantID: "mi-0000000000000004"},
+		{name: "by name and tags", instanceName: "aem-publish", instanceTags: map[string]string{"Env": "dev"}, wantID: "i-0000000000000002"},
+		{name: "by tags only", instanceTags: map[string]string{"Env": "prod"}, wantID: "mi-0000000000000004"},
+		{name: "offline node skipped", instanceName: "aem-preview", wantError: "no online managed node found"},
+		{name: "ambiguous tags", instanceTags: map[string]string{"Env": "dev"}, wantError: "multiple managed nodes found"},
+	}
+	client := testSSMClient(t, nodes)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := &AWSSSMConnection{
+				instanceID:   tt.instanceID,
+				instanceName: tt.instanceName,
+				instanceTags: tt.instanceTags,
+				context:      context.Background(),
+			}
+			err := connection.resolveInstanceID(client)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing '%s', got: %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if connection.instanceID != tt.wantID {
+				t.Errorf("instance ID = '%s', want '%s'", connection.instanceID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestSettingsWithPrefix(t *testing.T) {
+	settings := map[string]string{"instance_name": "aem-author", "tag:Env": "dev", "tag:Team": "aem"}
+	got := settingsWithPrefix(settings, awsInstanceTagPrefix)
+	if len(got) != 2 || got["Env"] != "dev" || got["Team"] != "aem" {
+		t.Errorf("settingsWithPrefix() = %v, want tags 'Env' and 'Team'", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/melbahja/goph"
 	"github.com/spf13/cast"
 	"golang.org/x/crypto/ssh"
+	"net"
 	"strings"
 )
 
@@ -18,6 +19,10 @@ type SSHConnection struct {
 	privateKeyPassphrase string
 	port                 int
 	secure               bool
+	hostKey              string
+
+	signer ssh.Signer
+	dial   func() (net.Conn, error)
 }
 
 func (s *SSHConnection) Connect() error {
@@ -27,41 +32,34 @@ func (s *SSHConnection) Connect() error {
 	if s.user == "" {
 		return fmt.Errorf("ssh: user is required")
 	}
-	if s.privateKey == "" {
+	if s.privateKey == "" && s.signer == nil {
 		return fmt.Errorf("ssh: private key is required")
 	}
 	if s.port == 0 {
 		s.port = 22
 	}
-	var (
-		signer ssh.Signer
-		err    error
-	)
-	if s.passphrase != "" {
-		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(s.privateKey), []byte(s.passphrase))
-		if err != nil {
-			return fmt.Errorf("ssh: cannot parse private key with passphrase: %w", err)
-		}
-	} else {
-		signer, err = ssh.ParsePrivateKey([]byte(s.privateKey))
-		if err != nil {
-			return fmt.Errorf("ssh: cannot parse private key: %w", err)
-		}
+	signer, err := s.authSigner()
+	if err != nil {
+		return err
 	}
-	var callback ssh.HostKeyCallback
-	if s.secure {
-		callback = ssh.FixedHostKey(signer.PublicKey())
-	} else {
-		callback = ssh.InsecureIgnoreHostKey()
+	callback, err := s.hostKeyCallback(signer)
+	if err != nil {
+		return err
 	}
-	client, err := goph.NewConn(&goph.Config{
+	config := &goph.Config{
 		User:     s.user,
 		Addr:     s.host,
 		Port:     cast.ToUint(s.port),
 		Auth:     goph.Auth{ssh.PublicKeys(signer)},
 		Timeout:  goph.DefaultTimeout,
 		Callback: callback,
-	})
+	}
+	var client *goph.Client
+	if s.dial != nil {
+		client, err = s.connectWithDial(config)
+	} else {
+		client, err = goph.NewConn(config)
+	}
 	if err != nil {
 		return fmt.Errorf("ssh: cannot connect to host '%s': %w", s.host, err)
 	}
@@ -69,6 +67,59 @@ func (s *SSHConnection) Connect() error {
 	return nil
 }
 
+// hostKeyCallback verifies the host key only if the connection is secure; the expected host key is given in the 'authorized_keys' format.
+func (s *SSHConnection) hostKeyCallback(signer ssh.Signer) (ssh.HostKeyCallback, error) {
+	if !s.secure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if s.hostKey == "" {
+		return ssh.FixedHostKey(signer.PublicKey()), nil
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s.hostKey))
+	if err != nil {
+		return nil, fmt.Errorf("ssh: cannot parse host key: %w", err)
+	}
+	return ssh.FixedHostKey(hostKey), nil
+}
+
+func (s *SSHConnection) authSigner() (ssh.Signer, error) {
+	if s.signer != nil {
+		return s.signer, nil
+	}
+	if s.passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase([]byte(s.privateKey), []byte(s.passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("ssh: cannot parse private key with passphrase: %w", err)
+		}
+		return signer, nil
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(s.privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("ssh: cannot parse private key: %w", err)
+	}
+	return signer, nil
+}
+
+// connectWithDial establishes SSH connection over a custom transport (e.g. tunnel) instead of plain TCP.
+func (s *SSHConnection) connectWithDial(config *goph.Config) (*goph.Client, error) {
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(config.Addr, fmt.Sprint(config.Port))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            config.Auth,
+		HostKeyCallback: config.Callback,
+		Timeout:         config.Timeout,
+	})
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &goph.Client{Client: ssh.NewClient(sshConn, chans, reqs), Config: config}, nil
+}
+
 func (s *SSHConnection) Info() string {
 	return fmt.Sprintf("ssh: host='%s', user='%s', port='%d'", s.host, s.user, s.port)
 }
@@ -11,6 +11,27 @@ var ConfigYML string
 //go:embed systemd.conf
 var ServiceConf string
 
+const (
+	ActionTimeoutDefault  = "10m"
+	StateTimeoutDefault   = "30s"
+	DataDirDefault        = "/mnt/aemc"
+	WorkDirDefault        = "/tmp/aemc"
+	ComposeVersionDefault = "1.6.12"
+
+	ReadinessTimeoutDefault  = "10m"
+	ReadinessIntervalDefault = "10s"
+	ReadinessUserDefault     = "admin"
+
+	ArtifactRetriesDefault        = 3
+	ArtifactCacheDirDefault       = "/mnt/aemc-cache"
+	ArtifactCacheMaxSizeMBDefault = 20480
+
+	ComposeCLISourceDefault          = "https://github.com/wttech/aemc/releases/download/v[[.VERSION]]/aemc-cli_[[.OS]]_[[.ARCH]].tar.gz"
+	ComposeCLIChecksumsSourceDefault = "https://github.com/wttech/aemc/releases/download/v[[.VERSION]]/checksums.txt"
+)
+
+var ReadinessAttributesDefault = []string{"running"}
+
 var CreateScriptInline = []string{
 	`sh aemw instance init`,
 	`sh aemw instance create`,
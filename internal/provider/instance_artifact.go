@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+	"github.com/wttech/terraform-provider-aem/internal/utils"
+	"golang.org/x/exp/slices"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type InstanceArtifactModel struct {
+	Source      types.String `tfsdk:"source"`
+	Destination types.String `tfsdk:"destination"`
+	Checksum    types.String `tfsdk:"checksum"`
+	Retries     types.Int64  `tfsdk:"retries"`
+}
+
+type InstanceArtifactCacheModel struct {
+	Dir       types.String `tfsdk:"dir"`
+	MaxSizeMB types.Int64  `tfsdk:"max_size_mb"`
+}
+
+func (ic *InstanceClient) fetchArtifacts() error {
+	for _, artifact := range ic.data.Artifact {
+		if err := ic.fetchArtifact(artifact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ic *InstanceClient) fetchArtifact(artifact InstanceArtifactModel) error {
+	source := artifact.Source.ValueString()
+	checksum := artifact.Checksum.ValueString()
+	remotePath := ic.artifactPath(artifact)
+
+	exists, err := ic.cl.FileExists(remotePath)
+	if err != nil {
+		return fmt.Errorf("cannot check if artifact '%s' exists: %w", remotePath, err)
+	}
+	if exists && checksum == "" {
+		tflog.Info(ic.ctx, fmt.Sprintf("Skipping artifact '%s' as it is already fetched", remotePath))
+		return nil
+	}
+	if exists && ic.verifyChecksum(remotePath, checksum) == nil {
+		tflog.Info(ic.ctx, fmt.Sprintf("Skipping artifact '%s' as it is already fetched", remotePath))
+		if ic.data.ArtifactCache != nil {
+			return ic.cacheArtifact(checksum, remotePath)
+		}
+		return nil
+	}
+	if err := ic.cl.DirEnsure(filepath.Dir(remotePath)); err != nil {
+		return err
+	}
+	if checksum != "" && ic.data.ArtifactCache != nil {
+		restored, err := ic.restoreCachedArtifact(checksum, remotePath)
+		if err != nil {
+			return err
+		}
+		if restored {
+			return nil
+		}
+	}
+
+	retries := artifactRetries(artifact)
+	err = withRetries(ic.ctx, retries, artifactRetryDelay, func(attempt int) error {
+		err := ic.downloadArtifact(source, remotePath)
+		if err == nil && checksum != "" {
+			err = ic.verifyChecksum(remotePath, checksum)
+		}
+		if err != nil {
+			_ = ic.cl.PathDelete(remotePath)
+			tflog.Warn(ic.ctx, fmt.Sprintf("Unable to fetch artifact '%s' (attempt %d/%d): %s", source, attempt, retries, err))
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to fetch artifact '%s': %w", source, err)
+	}
+	tflog.Info(ic.ctx, fmt.Sprintf("Fetched artifact '%s' to '%s'", source, remotePath))
+	if checksum != "" && ic.data.ArtifactCache != nil {
+		return ic.cacheArtifact(checksum, remotePath)
+	}
+	return nil
+}
+
+// artifactRetryDelay is multiplied by the attempt number, so that subsequent attempts wait longer.
+const artifactRetryDelay = 5 * time.Second
+
+func artifactRetries(artifact InstanceArtifactModel) int {
+	if !artifact.Retries.IsNull() && artifact.Retries.ValueInt64() > 0 {
+		return int(artifact.Retries.ValueInt64())
+	}
+	return instance.ArtifactRetriesDefault
+}
+
+// withRetries performs the action until it succeeds or all attempts fail; the delay between attempts grows linearly.
+func withRetries(ctx context.Context, attempts int, delay time.Duration, action func(attempt int) error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = action(attempt); err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("interrupted after %d attempt(s): %w", attempt, ctx.Err())
+			case <-time.After(time.Duration(attempt) * delay):
+			}
+		}
+	}
+	return fmt.Errorf("failed after %d attempt(s): %w", attempts, err)
+}
+
+// downloadArtifact places the artifact under a temporary path first, so that an interrupted download is never taken as an already fetched artifact.
+func (ic *InstanceClient) downloadArtifact(source string, remotePath string) error {
+	tmpPath := fmt.Sprintf("%s.tmp", remotePath)
+	switch {
+	case utils.IsURL(source):
+		if _, err := ic.cl.RunShellPurely(fmt.Sprintf("curl -fsSL -o '%s' '%s' && mv '%s' '%s'", tmpPath, source, tmpPath, remotePath)); err != nil {
+			_ = ic.cl.PathDelete(tmpPath)
+			return fmt.Errorf("cannot download artifact on remote machine: %w", err)
+		}
+	case strings.HasPrefix(source, "s3://"):
+		if _, err := ic.cl.RunShellPurely(fmt.Sprintf("aws s3 cp --only-show-errors '%s' '%s' && mv '%s' '%s'", source, tmpPath, tmpPath, remotePath)); err != nil {
+			_ = ic.cl.PathDelete(tmpPath)
+			return fmt.Errorf("cannot download artifact from S3 on remote machine: %w", err)
+		}
+	default:
+		if err := ic.cl.FileCopy(source, remotePath, true); err != nil {
+			return fmt.Errorf("cannot upload artifact to remote machine: %w", err)
+		}
+	}
+	return nil
+}
+
+// artifactPath resolves the artifact destination; relative paths are placed under the data directory.
+func (ic *InstanceClient) artifactPath(artifact InstanceArtifactModel) string {
+	destination := artifact.Destination.ValueString()
+	if strings.HasPrefix(destination, "/") {
+		return destination
+	}
+	return fmt.Sprintf("%s/%s", ic.dataDir(), destination)
+}
+
+func (ic *InstanceClient) artifactCacheDir() string {
+	return stringWithDefault(ic.data.ArtifactCache.Dir, types.StringValue(instance.ArtifactCacheDirDefault)).ValueString()
+}
+
+// artifactCachePath returns the content-addressed path of the cached artifact.
+func (ic *InstanceClient) artifactCachePath(checksum string) string {
+	return fmt.Sprintf("%s/%s", ic.artifactCacheDir(), strings.ToLower(strings.TrimSpace(checksum)))
+}
+
+// restoreCachedArtifact puts the cached artifact into place; hardlinks are preferred to avoid copying large files.
+func (ic *InstanceClient) restoreCachedArtifact(checksum string, remotePath string) (bool, error) {
+	cachePath := ic.artifactCachePath(checksum)
+	exists, err := ic.cl.FileExists(cachePath)
+	if err != nil {
+		return false, fmt.Errorf("cannot check if artifact '%s' is cached: %w", cachePath, err)
+	}
+	if !exists {
+		return false, nil
+	}
+	if err := ic.verifyChecksum(cachePath, checksum); err != nil {
+		tflog.Warn(ic.ctx, fmt.Sprintf("Evicting cached artifact '%s' as it is not valid: %s", cachePath, err))
+		return false, ic.cl.PathDelete(cachePath)
+	}
+	if _, err := ic.cl.RunShellPurely(fmt.Sprintf("touch -c '%s' && (ln -f '%s' '%s' 2>/dev/null || cp -f '%s' '%s')", cachePath, cachePath, remotePath, cachePath, remotePath)); err != nil {
+		return false, fmt.Errorf("cannot restore cached artifact '%s' to '%s': %w", cachePath, remotePath, err)
+	}
+	tflog.Info(ic.ctx, fmt.Sprintf("Restored artifact '%s' from cache '%s'", remotePath, cachePath))
+	return true, nil
+}
+
+func (ic *InstanceClient) cacheArtifact(checksum string, remotePath string) error {
+	cachePath := ic.artifactCachePath(checksum)
+	if err := ic.cl.DirEnsure(ic.artifactCacheDir()); err != nil {
+		return err
+	}
+	if _, err := ic.cl.RunShellPurely(fmt.Sprintf("ln -f '%s' '%s' 2>/dev/null || cp -f '%s' '%s'", remotePath, cachePath, remotePath, cachePath)); err != nil {
+		return fmt.Errorf("cannot cache artifact '%s' as '%s': %w", remotePath, cachePath, err)
+	}
+	tflog.Info(ic.ctx, fmt.Sprintf("Cached artifact '%s' as '%s'", remotePath, cachePath))
+	return ic.evictCachedArtifacts(cachePath)
+}
+
+type cachedArtifact struct {
+	path     string
+	size     int64
+	accessed float64
+}
+
+// evictCachedArtifacts deletes the least recently used artifacts until the cache fits its size limit; the recently cached one is kept anyway.
+func (ic *InstanceClient) evictCachedArtifacts(keptPath string) error {
+	maxSizeMB := int64(instance.ArtifactCacheMaxSizeMBDefault)
+	if !ic.data.ArtifactCache.MaxSizeMB.IsNull() {
+		maxSizeMB = ic.data.ArtifactCache.MaxSizeMB.ValueInt64()
+	}
+	out, err := ic.cl.RunShellPurely(fmt.Sprintf("find '%s' -maxdepth 1 -type f -printf '%%T@ %%s %%p\\n'", ic.artifactCacheDir()))
+	if err != nil {
+		return fmt.Errorf("cannot list cached artifacts: %w", err)
+	}
+	for _, artifact := range cachedArtifactsToEvict(parseCachedArtifacts(string(out)), maxSizeMB*1024*1024, keptPath) {
+		if err := ic.cl.PathDelete(artifact.path); err != nil {
+			return fmt.Errorf("cannot evict cached artifact: %w", err)
+		}
+		tflog.Info(ic.ctx, fmt.Sprintf("Evicted cached artifact '%s'", artifact.path))
+	}
+	return nil
+}
+
+// parseCachedArtifacts reads the listing of cached artifacts in the 'find -printf' format '%T@ %s %p'; malformed lines are skipped.
+func parseCachedArtifacts(listing string) []cachedArtifact {
+	var artifacts []cachedArtifact
+	for _, line := range strings.Split(strings.TrimSpace(listing), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		accessed, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, cachedArtifact{path: fields[2], size: size, accessed: accessed})
+	}
+	return artifacts
+}
+
+// cachedArtifactsToEvict selects the least recently used artifacts to be deleted until the cache fits the size limit; the kept one is never selected.
+func cachedArtifactsToEvict(artifacts []cachedArtifact, maxSize int64, keptPath string) []cachedArtifact {
+	var totalSize int64
+	for _, artifact := range artifacts {
+		totalSize += artifact.size
+	}
+	artifacts = slices.Clone(artifacts)
+	sort.SliceStable(artifacts, func(i, j int) bool { return artifacts[i].accessed < artifacts[j].accessed })
+	var evicted []cachedArtifact
+	for _, artifact := range artifacts {
+		if totalSize <= maxSize {
+			break
+		}
+		if artifact.path == keptPath {
+			continue
+		}
+		evicted = append(evicted, artifact)
+		totalSize -= artifact.size
+	}
+	return evicted
+}
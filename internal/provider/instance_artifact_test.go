@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+)
+
+func TestArtifactRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries types.Int64
+		want    int
+	}{
+		{name: "default when not set", retries: types.Int64Null(), want: instance.ArtifactRetriesDefault},
+		{name: "default when not positive", retries: types.Int64Value(0), want: instance.ArtifactRetriesDefault},
+		{name: "configured", retries: types.Int64Value(5), want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := artifactRetries(InstanceArtifactModel{Retries: tt.retries}); got != tt.want {
+				t.Errorf("artifactRetries() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name         string
+		attempts     int
+		failures     int
+		wantAttempts int
+		wantError    string
+	}{
+		{name: "first attempt succeeds", attempts: 3, failures: 0, wantAttempts: 1},
+		{name: "succeeds after failures", attempts: 3, failures: 2, wantAttempts: 3},
+		{name: "all attempts fail", attempts: 3, failures: 3, wantAttempts: 3, wantError: "failed after 3 attempt(s): failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []int
+			err := withRetries(context.Background(), tt.attempts, time.Millisecond, func(attempt int) error {
+				attempts = append(attempts, attempt)
+				if attempt <= tt.failures {
+					return errFailed
+				}
+				return nil
+			})
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError || !errors.Is(err, errFailed) {
+					t.Fatalf("expected error '%s', got: %v", tt.wantError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(attempts) != tt.wantAttempts || attempts[len(attempts)-1] != tt.wantAttempts {
+				t.Errorf("attempts = %v, want %d consecutive ones", attempts, tt.wantAttempts)
+			}
+		})
+	}
+
+	t.Run("interrupted while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		err := withRetries(ctx, 3, time.Hour, func(attempt int) error {
+			attempts++
+			cancel()
+			return errFailed
+		})
+		if err == nil || !strings.Contains(err.Error(), "interrupted after 1 attempt(s)") || !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected interruption error, got: %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("attempts = %d, want 1", attempts)
+		}
+	})
+}
+
+func TestParseCachedArtifacts(t *testing.T) {
+	listing := "1700000100.5000000000 1048576 /mnt/aemc-cache/aaa\n" +
+		"1700000000.0000000000 2048 /mnt/aemc-cache/with space\n" +
+		"malformed\n" +
+		"not-a-time 10 /mnt/aemc-cache/bbb\n"
+	want := []cachedArtifact{
+		{path: "/mnt/aemc-cache/aaa", size: 1048576, accessed: 1700000100.5},
+		{path: "/mnt/aemc-cache/with space", size: 2048, accessed: 1700000000},
+	}
+	if got := parseCachedArtifacts(listing); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCachedArtifacts() = %v, want %v", got, want)
+	}
+	if got := parseCachedArtifacts(""); len(got) != 0 {
+		t.Errorf("parseCachedArtifacts() of empty listing = %v, want none", got)
+	}
+}
+
+func TestCachedArtifactsToEvict(t *testing.T) {
+	artifacts := []cachedArtifact{
+		{path: "newest", size: 40, accessed: 300},
+		{path: "oldest", size: 30, accessed: 100},
+		{path: "middle", size: 20, accessed: 200},
+	}
+	tests := []struct {
+		name     string
+		maxSize  int64
+		keptPath string
+		want     []string
+	}{
+		{name: "fits the limit", maxSize: 90, keptPath: "newest", want: nil},
+		{name: "least recently used evicted first", maxSize: 60, keptPath: "newest", want: []string{"oldest"}},
+		{name: "evicted until fits", maxSize: 40, keptPath: "newest", want: []string{"oldest", "middle"}},
+		{name: "kept artifact skipped even if oldest", maxSize: 50, keptPath: "oldest", want: []string{"middle", "newest"}},
+		{name: "kept artifact left even if exceeding the limit", maxSize: 10, keptPath: "newest", want: []string{"oldest", "middle"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, artifact := range cachedArtifactsToEvict(artifacts, tt.maxSize, tt.keptPath) {
+				got = append(got, artifact.path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cachedArtifactsToEvict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if artifacts[0].path != "newest" {
+		t.Error("cachedArtifactsToEvict() reordered the given artifacts")
+	}
+}
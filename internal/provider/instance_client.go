@@ -1,11 +1,19 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/spf13/cast"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
 	"github.com/wttech/terraform-provider-aem/internal/utils"
 	"golang.org/x/exp/maps"
 	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -36,30 +44,299 @@ func (ic *InstanceClient) installComposeCLI() error {
 		tflog.Info(ic.ctx, "Skipping AEM Compose CLI wrapper download. It is expected to be alternatively installed under the data directory.")
 		return nil
 	}
-	exists, err := ic.cl.FileExists(fmt.Sprintf("%s/aemw", ic.dataDir()))
+	version := ic.data.Compose.Version.ValueString()
+	exists, err := ic.cl.FileExists(ic.composeWrapperPath())
 	if err != nil {
 		return fmt.Errorf("cannot check if AEM Compose CLI wrapper is installed: %w", err)
 	}
-	if !exists {
-		tflog.Info(ic.ctx, "Downloading AEM Compose CLI wrapper")
-		out, err := ic.cl.RunShellCommand("curl -s 'https://raw.githubusercontent.com/wttech/aemc/main/pkg/project/common/aemw' -o 'aemw'", ic.dataDir())
+	if exists {
+		installedVersion, err := ic.ReadComposeVersion()
+		if err != nil {
+			return err
+		}
+		if installedVersion == version {
+			return nil
+		}
+		tflog.Info(ic.ctx, fmt.Sprintf("Upgrading AEM Compose CLI from version '%s' to '%s'", installedVersion, version))
+	}
+	if err := ic.installComposeCLIBinary(); err != nil {
+		return err
+	}
+	if err := ic.cl.FileWrite(ic.composeVersionPath(), version); err != nil {
+		return fmt.Errorf("cannot save installed AEM Compose CLI version: %w", err)
+	}
+	if ic.data.Compose.PruneVersions.ValueBool() {
+		if err := ic.pruneComposeVersions(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadComposeVersion returns the version of AEM Compose CLI installed by the provider or an empty string if unknown.
+func (ic *InstanceClient) ReadComposeVersion() (string, error) {
+	version, err := ic.readFileIfExists(ic.composeVersionPath())
+	if err != nil {
+		return "", fmt.Errorf("cannot read installed AEM Compose CLI version: %w", err)
+	}
+	return strings.TrimSpace(version), nil
+}
+
+// pruneComposeVersions removes directories of AEM Compose CLI versions other than the configured one.
+func (ic *InstanceClient) pruneComposeVersions() error {
+	versionsDir := filepath.Dir(ic.composeCLIDir())
+	cmd := fmt.Sprintf("test ! -d %s || find %s -mindepth 1 -maxdepth 1 -type d ! -name '%s' -exec rm -rf {} +", versionsDir, versionsDir, ic.data.Compose.Version.ValueString())
+	if _, err := ic.cl.RunShellPurely(cmd); err != nil {
+		return fmt.Errorf("cannot prune old AEM Compose CLI versions in '%s': %w", versionsDir, err)
+	}
+	tflog.Info(ic.ctx, "Pruned old AEM Compose CLI versions")
+	return nil
+}
+
+func (ic *InstanceClient) composeWrapperPath() string {
+	return fmt.Sprintf("%s/aemw", ic.dataDir())
+}
+
+func (ic *InstanceClient) composeVersionPath() string {
+	return fmt.Sprintf("%s/provider/compose.version", ic.dataDir())
+}
+
+func (ic *InstanceClient) composeCLIDir() string {
+	return fmt.Sprintf("%s/aem/home/opt/aemc/%s", ic.dataDir(), ic.data.Compose.Version.ValueString())
+}
+
+// installComposeCLIBinary places the CLI binary in the data directory and generates a wrapper delegating to it, so that nothing is downloaded by the wrapper itself.
+func (ic *InstanceClient) installComposeCLIBinary() error {
+	source, err := ic.composeCLISource()
+	if err != nil {
+		return err
+	}
+	checksum, err := ic.composeCLIChecksum(source)
+	if err != nil {
+		return err
+	}
+	tflog.Info(ic.ctx, fmt.Sprintf("Installing AEM Compose CLI from '%s'", source))
+
+	cliDir := ic.composeCLIDir()
+	binPath := fmt.Sprintf("%s/aem", cliDir)
+	if err := ic.cl.DirEnsure(cliDir); err != nil {
+		return err
+	}
+	fileName := utils.URLFileName(source)
+	if strings.HasSuffix(fileName, ".tar.gz") || strings.HasSuffix(fileName, ".tgz") {
+		archivePath := fmt.Sprintf("%s/%s", cliDir, fileName)
+		if err := ic.fetchFile(source, archivePath, checksum); err != nil {
+			return fmt.Errorf("cannot download AEM Compose CLI archive: %w", err)
+		}
+		if _, err := ic.cl.RunShellPurely(fmt.Sprintf("tar -xzf %s -C %s && rm -f %s", archivePath, cliDir, archivePath)); err != nil {
+			return fmt.Errorf("cannot extract AEM Compose CLI archive '%s': %w", archivePath, err)
+		}
+		exists, err := ic.cl.FileExists(binPath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("AEM Compose CLI archive '%s' does not contain binary 'aem'", source)
+		}
+	} else if err := ic.fetchFile(source, binPath, checksum); err != nil {
+		return fmt.Errorf("cannot download AEM Compose CLI binary: %w", err)
+	}
+	if err := ic.cl.FileMakeExecutable(binPath); err != nil {
+		return err
+	}
+	if err := ic.cl.FileWrite(ic.composeWrapperPath(), fmt.Sprintf("#!/usr/bin/env sh\n\nexec '%s' \"$@\"\n", binPath)); err != nil {
+		return fmt.Errorf("cannot write AEM Compose CLI wrapper: %w", err)
+	}
+	tflog.Info(ic.ctx, "Installed AEM Compose CLI")
+	return nil
+}
+
+// composeCLISource resolves the placeholders in the CLI source using the OS and architecture of the remote machine.
+func (ic *InstanceClient) composeCLISource() (string, error) {
+	out, err := ic.cl.RunShellPurely("uname -s -m")
+	if err != nil {
+		return "", fmt.Errorf("cannot detect OS and architecture of the remote machine: %w", err)
+	}
+	platform := strings.Fields(strings.ToLower(string(out)))
+	if len(platform) != 2 {
+		return "", fmt.Errorf("cannot detect OS and architecture of the remote machine: unexpected output '%s'", string(out))
+	}
+	arch := platform[1]
+	switch arch {
+	case "x86_64":
+		arch = "amd64"
+	case "aarch64":
+		arch = "arm64"
+	}
+	sourceTemplate := ic.data.Compose.CLISource.ValueString()
+	if sourceTemplate == "" {
+		sourceTemplate = instance.ComposeCLISourceDefault
+	}
+	source, err := utils.TemplateString(sourceTemplate, map[string]string{
+		"VERSION": ic.data.Compose.Version.ValueString(),
+		"OS":      platform[0],
+		"ARCH":    arch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to template AEM Compose CLI source: %w", err)
+	}
+	return source, nil
+}
+
+// composeCLIChecksum returns the configured checksum of the CLI file or looks it up in the checksums file published with the release.
+func (ic *InstanceClient) composeCLIChecksum(source string) (string, error) {
+	if checksum := ic.data.Compose.CLIChecksum.ValueString(); checksum != "" {
+		return checksum, nil
+	}
+	checksumsTemplate := ic.data.Compose.CLIChecksumsSource.ValueString()
+	if checksumsTemplate == "" {
+		checksumsTemplate = instance.ComposeCLIChecksumsSourceDefault
+	}
+	checksumsSource, err := utils.TemplateString(checksumsTemplate, map[string]string{
+		"VERSION": ic.data.Compose.Version.ValueString(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to template AEM Compose CLI checksums source: %w", err)
+	}
+	checksums, err := ic.fetchText(checksumsSource)
+	if err != nil {
+		return "", fmt.Errorf("cannot determine checksum of AEM Compose CLI '%s' (set 'cli_checksum' explicitly): %w", source, err)
+	}
+	fileName := utils.URLFileName(source)
+	checksum, ok := utils.ChecksumsFind(checksums, fileName)
+	if !ok {
+		return "", fmt.Errorf("cannot determine checksum of AEM Compose CLI '%s' (set 'cli_checksum' explicitly): file '%s' is not listed in '%s'", source, fileName, checksumsSource)
+	}
+	return checksum, nil
+}
+
+// fetchText reads the small text file from a URL or a local path (on the Terraform host).
+func (ic *InstanceClient) fetchText(source string) (string, error) {
+	if utils.IsURL(source) && !ic.data.Compose.LocalDownload.ValueBool() {
+		out, err := ic.cl.RunShellCommand(fmt.Sprintf("curl -fsSL --retry 3 '%s'", source), ".")
+		if err != nil {
+			return "", fmt.Errorf("cannot download file '%s' on remote machine: %w", source, err)
+		}
+		return string(out), nil
+	}
+	localPath := source
+	if utils.IsURL(source) {
+		cacheDir, err := ic.cacheDir()
+		if err != nil {
+			return "", err
+		}
+		localPath, err = utils.DownloadCached(ic.ctx, source, cacheDir)
+		if err != nil {
+			return "", err
+		}
+	}
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot read file '%s': %w", localPath, err)
+	}
+	return string(content), nil
+}
+
+// fetchFile places the file from a URL or a local path (on the Terraform host) at the remote path and verifies its SHA-256 checksum if specified.
+func (ic *InstanceClient) fetchFile(source string, remotePath string, checksum string) error {
+	localPath := ""
+	if utils.IsURL(source) && !ic.data.Compose.LocalDownload.ValueBool() {
+		if err := ic.cl.DirEnsure(filepath.Dir(remotePath)); err != nil {
+			return err
+		}
+		out, err := ic.cl.RunShellCommand(fmt.Sprintf("curl -fsSL --retry 3 -o '%s' '%s'", remotePath, source), ".")
 		tflog.Info(ic.ctx, string(out))
 		if err != nil {
-			return fmt.Errorf("cannot download AEM Compose CLI wrapper: %w", err)
+			return fmt.Errorf("cannot download file '%s' on remote machine: %w", source, err)
+		}
+	} else {
+		localPath = source
+		if utils.IsURL(source) {
+			cacheDir, err := ic.cacheDir()
+			if err != nil {
+				return err
+			}
+			localPath, err = utils.DownloadCached(ic.ctx, source, cacheDir)
+			if err != nil {
+				return err
+			}
+		}
+		if err := ic.cl.FileCopy(localPath, remotePath, true); err != nil {
+			return fmt.Errorf("unable to copy file '%s' to '%s': %w", localPath, remotePath, err)
+		}
+	}
+	if checksum == "" {
+		return nil
+	}
+	if err := ic.verifyChecksum(remotePath, checksum); err != nil {
+		_ = ic.cl.PathDelete(remotePath)
+		if localPath != "" && localPath != source {
+			_ = os.Remove(localPath) // do not keep an invalid file in the local cache
 		}
-		tflog.Info(ic.ctx, "Downloaded AEM Compose CLI wrapper")
+		return fmt.Errorf("file '%s' is not valid: %w", source, err)
+	}
+	return nil
+}
+
+func (ic *InstanceClient) verifyChecksum(remotePath string, expected string) error {
+	out, err := ic.cl.RunShellPurely(fmt.Sprintf("sha256sum %s", remotePath))
+	if err != nil {
+		return fmt.Errorf("cannot calculate checksum of file '%s': %w", remotePath, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return fmt.Errorf("cannot calculate checksum of file '%s': unexpected output '%s'", remotePath, string(out))
+	}
+	if actual := fields[0]; !strings.EqualFold(actual, strings.TrimSpace(expected)) {
+		return fmt.Errorf("SHA-256 checksum mismatch: expected '%s', actual '%s'", expected, actual)
 	}
 	return nil
 }
 
+func (ic *InstanceClient) cacheDir() (string, error) {
+	if dir := ic.data.Compose.CacheDir.ValueString(); dir != "" {
+		return dir, nil
+	}
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine local cache directory: %w", err)
+	}
+	return filepath.Join(userCacheDir, "terraform-provider-aem"), nil
+}
+
+func (ic *InstanceClient) configFilePath() string {
+	return fmt.Sprintf("%s/aem/default/etc/aem.yml", ic.dataDir())
+}
+
+// writeConfigFile writes the configuration owned by the service user, as it runs AEM instances, and readable by the group of the connecting user, as it runs AEM Compose commands.
 func (ic *InstanceClient) writeConfigFile() error {
-	configYAML := ic.data.Compose.Config.ValueString()
-	if err := ic.cl.FileWrite(fmt.Sprintf("%s/aem/default/etc/aem.yml", ic.dataDir()), configYAML); err != nil {
+	configYAML, err := ic.composeConfig()
+	if err != nil {
+		return err
+	}
+	configFile := ic.configFilePath()
+
+	ic.cl.Sudo = true
+	defer func() { ic.cl.Sudo = false }()
+
+	if err := ic.cl.FileWriteMode(configFile, configYAML, "640"); err != nil {
 		return fmt.Errorf("unable to copy AEM configuration file: %w", err)
 	}
+	if _, err := ic.cl.RunShellPurely(fmt.Sprintf("chown %s:$(id -gn %s) %s", ic.serviceUser(), ic.cl.Connection().User(), configFile)); err != nil {
+		return fmt.Errorf("unable to set ownership of AEM configuration file '%s': %w", configFile, err)
+	}
 	return nil
 }
 
+// composeConfig returns the contents of the AEM Compose configuration file to be written on the machine.
+func (ic *InstanceClient) composeConfig() (string, error) {
+	config, err := renderComposeConfig(ic.ctx, ic.data)
+	if err != nil {
+		return "", err
+	}
+	return injectComposeSecrets(ic.ctx, config, ic.data)
+}
+
 func (ic *InstanceClient) copyFiles() error {
 	var filesMap map[string]string
 	ic.data.Files.ElementsAs(ic.ctx, &filesMap, true)
@@ -71,14 +348,25 @@ func (ic *InstanceClient) copyFiles() error {
 	return nil
 }
 
-func (ic *InstanceClient) create() error {
-	tflog.Info(ic.ctx, "Creating AEM instance(s)")
-	if err := ic.configureService(); err != nil {
-		return err
+// configureSystem writes the system service definition and environment variables, also when updating to revert any changes made outside of Terraform.
+// Returns true if the service definition or secret environment variables changed, so that the running service needs to be restarted to pick them up.
+func (ic *InstanceClient) configureSystem() (bool, error) {
+	serviceChanged, err := ic.configureService()
+	if err != nil {
+		return false, err
 	}
 	if err := ic.saveProfileScript(); err != nil {
-		return err
+		return false, err
+	}
+	secretEnvChanged, err := ic.saveSecretEnvFile()
+	if err != nil {
+		return false, err
 	}
+	return serviceChanged || secretEnvChanged, nil
+}
+
+func (ic *InstanceClient) create() error {
+	tflog.Info(ic.ctx, "Creating AEM instance(s)")
 	if err := ic.runScript("create", ic.data.Compose.Create, ic.dataDir()); err != nil {
 		return err
 	}
@@ -86,8 +374,49 @@ func (ic *InstanceClient) create() error {
 	return nil
 }
 
+func (ic *InstanceClient) profileScriptPath() string {
+	return fmt.Sprintf("/etc/profile.d/%s.sh", ServiceName)
+}
+
+func (ic *InstanceClient) serviceFilePath() string {
+	return fmt.Sprintf("/etc/systemd/system/%s.service", ServiceName)
+}
+
+func secretEnvFilePath(dataDir string) string {
+	return fmt.Sprintf("%s/provider/secret.env", dataDir)
+}
+
+// saveSecretEnvFile writes the sensitive environment variables to a file readable only by the service user; written also when empty to drop removed ones.
+// Returns true if the contents of the file changed.
+func (ic *InstanceClient) saveSecretEnvFile() (bool, error) {
+	envFile := secretEnvFilePath(ic.dataDir())
+
+	var secretEnvMap map[string]string
+	ic.data.System.SecretEnv.ElementsAs(ic.ctx, &secretEnvMap, true)
+	envText := utils.EnvToFile(secretEnvMap)
+
+	if err := ic.cl.DirEnsure(filepath.Dir(envFile)); err != nil {
+		return false, err
+	}
+
+	ic.cl.Sudo = true
+	defer func() { ic.cl.Sudo = false }()
+
+	envTextCurrent, err := ic.readFileIfExists(envFile)
+	if err != nil {
+		return false, fmt.Errorf("unable to read AEM secret environment variables file '%s': %w", envFile, err)
+	}
+	if err := ic.cl.FileWriteMode(envFile, envText, "600"); err != nil {
+		return false, fmt.Errorf("unable to write AEM secret environment variables file '%s': %w", envFile, err)
+	}
+	if _, err := ic.cl.RunShellPurely(fmt.Sprintf("chown %s %s", ic.serviceUser(), envFile)); err != nil {
+		return false, fmt.Errorf("unable to set ownership of AEM secret environment variables file '%s': %w", envFile, err)
+	}
+	return envTextCurrent != envText, nil
+}
+
 func (ic *InstanceClient) saveProfileScript() error {
-	envFile := fmt.Sprintf("/etc/profile.d/%s.sh", ServiceName)
+	envFile := ic.profileScriptPath()
 
 	var systemEnvMap map[string]string
 	ic.data.System.Env.ElementsAs(ic.ctx, &systemEnvMap, true)
@@ -105,30 +434,70 @@ func (ic *InstanceClient) saveProfileScript() error {
 	return nil
 }
 
-func (ic *InstanceClient) configureService() error {
+func (ic *InstanceClient) serviceUser() string {
 	user := ic.data.System.User.ValueString()
 	if user == "" {
 		user = ic.cl.Connection().User()
 	}
+	return user
+}
+
+var serviceSecretEnvFileRegex = regexp.MustCompile(`\[\[\s*\.SECRET_ENV_FILE\s*]]`)
+
+// serviceConfigLoadsSecretEnv checks if the system service definition refers to the secret environment variables file.
+func serviceConfigLoadsSecretEnv(serviceConfig string) bool {
+	return serviceSecretEnvFileRegex.MatchString(serviceConfig)
+}
+
+func (ic *InstanceClient) templateServiceConfig(serviceConfig string, user string) (string, error) {
 	vars := map[string]string{
-		"DATA_DIR": ic.dataDir(),
-		"USER":     user,
+		"DATA_DIR":        ic.dataDir(),
+		"USER":            user,
+		"SECRET_ENV_FILE": secretEnvFilePath(ic.dataDir()),
 	}
+	serviceTemplated, err := utils.TemplateString(serviceConfig, vars)
+	if err != nil {
+		return "", fmt.Errorf("unable to template AEM system service definition: %w", err)
+	}
+	return serviceTemplated, nil
+}
 
+// configureService writes the system service definition and reloads systemd if it changed; returns true in such case.
+func (ic *InstanceClient) configureService() (bool, error) {
 	ic.cl.Sudo = true
 	defer func() { ic.cl.Sudo = false }()
 
-	serviceTemplated, err := utils.TemplateString(ic.data.System.ServiceConfig.ValueString(), vars)
+	serviceTemplated, err := ic.templateServiceConfig(ic.data.System.ServiceConfig.ValueString(), ic.serviceUser())
 	if err != nil {
-		return fmt.Errorf("unable to template AEM system service definition: %w", err)
+		return false, err
+	}
+	serviceFile := ic.serviceFilePath()
+	serviceCurrent, err := ic.readFileIfExists(serviceFile)
+	if err != nil {
+		return false, fmt.Errorf("unable to read AEM system service definition '%s': %w", serviceFile, err)
 	}
-	serviceFile := fmt.Sprintf("/etc/systemd/system/%s.service", ServiceName)
 	if err := ic.cl.FileWrite(serviceFile, serviceTemplated); err != nil {
-		return fmt.Errorf("unable to write AEM system service definition '%s': %w", serviceFile, err)
+		return false, fmt.Errorf("unable to write AEM system service definition '%s': %w", serviceFile, err)
+	}
+	changed := serviceCurrent != serviceTemplated
+	if changed {
+		if err := ic.reloadSystemd(); err != nil {
+			return false, err
+		}
 	}
 
 	if err := ic.runServiceAction("enable"); err != nil {
-		return err
+		return false, err
+	}
+	return changed, nil
+}
+
+func (ic *InstanceClient) reloadSystemd() error {
+	ic.cl.Sudo = true
+	defer func() { ic.cl.Sudo = false }()
+
+	if _, err := ic.cl.RunShellCommand("systemctl daemon-reload", "."); err != nil {
+		return fmt.Errorf("unable to reload system service definitions: %w", err)
 	}
 	return nil
 }
@@ -146,9 +515,14 @@ func (ic *InstanceClient) runServiceAction(action string) error {
 	return nil
 }
 
-func (ic *InstanceClient) launch() error {
+// launch starts the system service or, if its definition or secret environment variables changed, restarts it to pick them up.
+func (ic *InstanceClient) launch(restart bool) error {
 	tflog.Info(ic.ctx, "Launching AEM instance(s)")
-	if err := ic.runServiceAction("start"); err != nil {
+	serviceAction := "start"
+	if restart {
+		serviceAction = "restart"
+	}
+	if err := ic.runServiceAction(serviceAction); err != nil {
 		return err
 	}
 	if err := ic.applyConfig(); err != nil {
@@ -192,18 +566,151 @@ func (ic *InstanceClient) deleteDataDir() error {
 	return nil
 }
 
+func (ic *InstanceClient) deploymentExists() (bool, error) {
+	exists, err := ic.cl.FileExists(ic.configFilePath())
+	if err != nil {
+		return false, fmt.Errorf("cannot check if AEM deployment exists: %w", err)
+	}
+	return exists, nil
+}
+
+// readDeployment updates the model with the AEM Compose configuration, system service definition and environment variables deployed on the machine if they differ from the model.
+func (ic *InstanceClient) readDeployment() error {
+	config, err := ic.readFileIfExists(ic.configFilePath())
+	if err != nil {
+		return err
+	}
+	expectedConfig, err := renderComposeConfig(ic.ctx, ic.data)
+	if err != nil {
+		return err
+	}
+	drifted, err := composeConfigDrifted(ic.ctx, config, expectedConfig)
+	if err != nil {
+		return err
+	}
+	if drifted {
+		tflog.Info(ic.ctx, fmt.Sprintf("AEM Compose configuration file '%s' differs from the expected one", ic.configFilePath()))
+		ic.data.Compose.Config = types.StringValue(redactComposeSecrets(config))
+	}
+
+	serviceConfig, err := ic.readFileIfExists(ic.serviceFilePath())
+	if err != nil {
+		return err
+	}
+	expectedServiceConfig, err := ic.templateServiceConfig(ic.data.System.ServiceConfig.ValueString(), ic.serviceUser())
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(serviceConfig) != strings.TrimSpace(expectedServiceConfig) {
+		tflog.Info(ic.ctx, fmt.Sprintf("AEM system service definition '%s' differs from the expected one", ic.serviceFilePath()))
+		ic.data.System.ServiceConfig = types.StringValue(serviceConfig)
+	}
+
+	profileScript, err := ic.readFileIfExists(ic.profileScriptPath())
+	if err != nil {
+		return err
+	}
+	env := map[string]string{}
+	for name, value := range parseEnvScript(profileScript) {
+		if _, ok := ic.cl.Env[name]; !ok {
+			env[name] = value
+		}
+	}
+	var expectedEnv map[string]string
+	ic.data.System.Env.ElementsAs(ic.ctx, &expectedEnv, true)
+	if !maps.Equal(env, expectedEnv) {
+		tflog.Info(ic.ctx, fmt.Sprintf("AEM environment variables file '%s' differs from the expected one", ic.profileScriptPath()))
+		ic.data.System.Env, _ = types.MapValueFrom(ic.ctx, types.StringType, env)
+	}
+	return nil
+}
+
+// composeConfigDrifted compares the AEM Compose configuration read from the machine with the expected one, ignoring formatting.
+// Write-only secrets are not known when reading, so they are excluded from the comparison.
+// An invalid configuration read is reported as drifted, so that it is written again; only an invalid expected one is an error.
+func composeConfigDrifted(ctx context.Context, config string, expectedConfig string) (bool, error) {
+	expectedConfig = stripComposeSecrets(expectedConfig)
+	if err := yaml.Unmarshal([]byte(expectedConfig), new(any)); err != nil {
+		return false, fmt.Errorf("cannot parse expected AEM Compose configuration: %w", err)
+	}
+	equal, err := yamlSemanticEqual(stripComposeSecrets(config), expectedConfig)
+	if err != nil {
+		tflog.Warn(ctx, fmt.Sprintf("AEM Compose configuration read from the machine cannot be parsed: %s", err))
+		return true, nil
+	}
+	return !equal, nil
+}
+
+// readFileIfExists returns an empty string for a missing file, so that it is reported as a difference instead of an error.
+func (ic *InstanceClient) readFileIfExists(path string) (string, error) {
+	exists, err := ic.cl.FileExists(path)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+	return ic.cl.FileRead(path)
+}
+
+var envScriptExportRegex = regexp.MustCompile(`^export ([A-Za-z_][A-Za-z0-9_]*)="(.*)"$`)
+
+// parseEnvScript reverses 'utils.EnvToScript'.
+func parseEnvScript(script string) map[string]string {
+	env := map[string]string{}
+	for _, line := range strings.Split(script, "\n") {
+		match := envScriptExportRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		value := strings.ReplaceAll(match[2], "\\$", "$")
+		value = strings.ReplaceAll(value, "\\\"", "\"")
+		env[match[1]] = value
+	}
+	return env
+}
+
 type InstanceStatus struct {
 	Data struct {
-		Instances []struct {
-			ID           string   `yaml:"id"`
-			URL          string   `yaml:"url"`
-			AemVersion   string   `yaml:"aem_version"`
-			Attributes   []string `yaml:"attributes"`
-			RunModes     []string `yaml:"run_modes"`
-			HealthChecks []string `yaml:"health_checks"`
-			Dir          string   `yaml:"dir"`
-		} `yaml:"instances"`
+		Instances []InstanceStatusItem `yaml:"instances"`
+	}
+}
+
+type InstanceStatusItem struct {
+	ID           string    `yaml:"id"`
+	URL          string    `yaml:"url"`
+	AemVersion   string    `yaml:"aem_version"`
+	Attributes   []string  `yaml:"attributes"`
+	RunModes     []string  `yaml:"run_modes"`
+	HealthChecks []string  `yaml:"health_checks"`
+	Dir          string    `yaml:"dir"`
+	StartTime    time.Time `yaml:"-"`
+}
+
+func (i InstanceStatusItem) hasAttribute(name string) bool {
+	for _, attribute := range i.Attributes {
+		if attribute == name {
+			return true
+		}
 	}
+	return false
+}
+
+func (i InstanceStatusItem) Running() bool {
+	return i.hasAttribute("running")
+}
+
+func (i InstanceStatusItem) UpToDate() bool {
+	return i.hasAttribute("up-to-date")
+}
+
+func (i InstanceStatusItem) Healthy() bool {
+	return i.Running() && len(i.HealthChecks) == 0
+}
+
+// Local checks if the instance is stored on the machine; remote ones are only referred to by URL.
+func (i InstanceStatusItem) Local() bool {
+	return i.Dir != ""
 }
 
 func (ic *InstanceClient) ReadStatus() (InstanceStatus, error) {
@@ -215,9 +722,24 @@ func (ic *InstanceClient) ReadStatus() (InstanceStatus, error) {
 	if err := yaml.Unmarshal(yamlBytes, &status); err != nil {
 		return status, fmt.Errorf("unable to parse AEM instance status: %w", err)
 	}
+	for i, instance := range status.Data.Instances {
+		if instance.Local() && instance.Running() {
+			status.Data.Instances[i].StartTime = ic.readStartTime(instance.Dir)
+		}
+	}
 	return status, nil
 }
 
+// readStartTime determines when the instance process was started based on the modification time of its PID file.
+func (ic *InstanceClient) readStartTime(instanceDir string) time.Time {
+	out, err := ic.cl.RunShellPurely(fmt.Sprintf("stat -c %%Y %s/crx-quickstart/conf/cq.pid", instanceDir))
+	if err != nil {
+		tflog.Info(ic.ctx, fmt.Sprintf("Cannot read start time of AEM instance stored in '%s': %s", instanceDir, err))
+		return time.Time{}
+	}
+	return time.Unix(cast.ToInt64(strings.TrimSpace(string(out))), 0).UTC()
+}
+
 func (ic *InstanceClient) bootstrap() error {
 	return ic.doActionOnce("bootstrap", ic.cl.WorkDir, func() error {
 		return ic.runScript("bootstrap", ic.data.System.Bootstrap, ".")
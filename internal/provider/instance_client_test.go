@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+	"github.com/wttech/terraform-provider-aem/internal/utils"
+)
+
+func TestServiceConfigLoadsSecretEnv(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceConfig string
+		want          bool
+	}{
+		{name: "default service definition", serviceConfig: instance.ServiceConf, want: true},
+		{name: "template variable with spaces", serviceConfig: "[Service]\nEnvironmentFile=[[ .SECRET_ENV_FILE ]]\n", want: true},
+		{name: "no environment file", serviceConfig: "[Service]\nUser=[[.USER]]\n", want: false},
+		{name: "other environment file", serviceConfig: "[Service]\nEnvironmentFile=-/etc/aem.env\n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceConfigLoadsSecretEnv(tt.serviceConfig); got != tt.want {
+				t.Errorf("serviceConfigLoadsSecretEnv() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   map[string]string
+	}{
+		{
+			name:   "empty",
+			script: "",
+			want:   map[string]string{},
+		},
+		{
+			name:   "exports parsed and unescaped",
+			script: "#!/bin/sh\nexport JAVA_HOME=\"/opt/java\"\nexport GREETING=\"say \\\"hi\\\" to \\$USER\"\n",
+			want:   map[string]string{"JAVA_HOME": "/opt/java", "GREETING": "say \"hi\" to $USER"},
+		},
+		{
+			name:   "other lines skipped",
+			script: "#!/bin/sh\n# comment\nPATH=/usr/bin\nexport 1INVALID=\"x\"\n  export INDENTED=\"yes\"  \n",
+			want:   map[string]string{"INDENTED": "yes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseEnvScript(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEnvScript() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("reverses script formatting", func(t *testing.T) {
+		env := map[string]string{"A": "plain", "B": `quoted "value"`, "C": "$HOME/dir"}
+		if got := parseEnvScript(utils.EnvToScript(env)); !reflect.DeepEqual(got, env) {
+			t.Errorf("parseEnvScript(EnvToScript()) = %v, want %v", got, env)
+		}
+	})
+}
+
+func TestComposeConfigDrifted(t *testing.T) {
+	const expected = "instance:\n  config:\n    local_author:\n      http_url: http://127.0.0.1:4502\nlog:\n  level: info\n"
+	tests := []struct {
+		name      string
+		config    string
+		expected  string
+		want      bool
+		wantError string
+	}{
+		{name: "same", config: expected, expected: expected, want: false},
+		{name: "formatting and comments ignored", config: "log: {level: info} # logging\ninstance:\n  config:\n    local_author: {http_url: 'http://127.0.0.1:4502'}\n", expected: expected, want: false},
+		{name: "secrets on the machine ignored", config: "instance:\n  config:\n    local_author:\n      http_url: http://127.0.0.1:4502\n      password: s3cr3t\n      secret_vars:\n        - TOKEN=abc\nlog:\n  level: info\n", expected: expected, want: false},
+		{name: "value changed", config: strings.Replace(expected, "info", "debug", 1), expected: expected, want: true},
+		{name: "file missing", config: "", expected: expected, want: true},
+		{name: "file on the machine invalid", config: "log: [", expected: expected, want: true},
+		{name: "expected config invalid", config: expected, expected: "log: [", wantError: "cannot parse expected AEM Compose configuration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := composeConfigDrifted(context.Background(), tt.config, tt.expected)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing '%s', got: %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("composeConfigDrifted() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
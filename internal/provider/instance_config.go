@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type InstanceComposeInstanceModel struct {
+	ID         types.String `tfsdk:"id"`
+	HTTPURL    types.String `tfsdk:"http_url"`
+	User       types.String `tfsdk:"user"`
+	Password   types.String `tfsdk:"password"`
+	RunModes   types.List   `tfsdk:"run_modes"`
+	JVMOpts    types.List   `tfsdk:"jvm_opts"`
+	StartOpts  types.List   `tfsdk:"start_opts"`
+	EnvVars    types.List   `tfsdk:"env_vars"`
+	SecretVars types.List   `tfsdk:"secret_vars"`
+	SlingProps types.List   `tfsdk:"sling_props"`
+}
+
+// composeInstanceConfig mirrors the instance entry in the AEM Compose configuration file.
+type composeInstanceConfig struct {
+	Active     bool     `yaml:"active"`
+	HTTPURL    string   `yaml:"http_url"`
+	User       string   `yaml:"user,omitempty"`
+	Password   string   `yaml:"password,omitempty"`
+	RunModes   []string `yaml:"run_modes,omitempty"`
+	JVMOpts    []string `yaml:"jvm_opts,omitempty"`
+	StartOpts  []string `yaml:"start_opts,omitempty"`
+	EnvVars    []string `yaml:"env_vars,omitempty"`
+	SecretVars []string `yaml:"secret_vars,omitempty"`
+	SlingProps []string `yaml:"sling_props,omitempty"`
+}
+
+func (m InstanceComposeInstanceModel) config(ctx context.Context) composeInstanceConfig {
+	config := composeInstanceConfig{
+		Active:   true,
+		HTTPURL:  m.HTTPURL.ValueString(),
+		User:     m.User.ValueString(),
+		Password: m.Password.ValueString(),
+	}
+	m.RunModes.ElementsAs(ctx, &config.RunModes, true)
+	m.JVMOpts.ElementsAs(ctx, &config.JVMOpts, true)
+	m.StartOpts.ElementsAs(ctx, &config.StartOpts, true)
+	m.EnvVars.ElementsAs(ctx, &config.EnvVars, true)
+	m.SecretVars.ElementsAs(ctx, &config.SecretVars, true)
+	m.SlingProps.ElementsAs(ctx, &config.SlingProps, true)
+	return config
+}
+
+// renderComposeConfig builds the AEM Compose configuration file from the base config, instance blocks and overrides.
+// The base config is returned as-is if neither instance blocks nor overrides are set; otherwise its comments are preserved.
+func renderComposeConfig(ctx context.Context, model InstanceResourceModel) (string, error) {
+	base := model.Compose.Config.ValueString()
+	overrides := model.Compose.Overrides.ValueString()
+	if len(model.Compose.Instance) == 0 && overrides == "" {
+		return base, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(base), &doc); err != nil {
+		return "", fmt.Errorf("cannot parse AEM Compose configuration: %w", err)
+	}
+	root := configDocumentRoot(&doc)
+	if root.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("AEM Compose configuration needs to be a YAML mapping")
+	}
+
+	if len(model.Compose.Instance) > 0 {
+		instances := map[string]composeInstanceConfig{}
+		for _, instanceModel := range model.Compose.Instance {
+			id := instanceModel.ID.ValueString()
+			if _, exists := instances[id]; exists {
+				return "", fmt.Errorf("AEM instance '%s' is defined more than once", id)
+			}
+			instances[id] = instanceModel.config(ctx)
+		}
+		var instancesNode yaml.Node
+		if err := instancesNode.Encode(instances); err != nil {
+			return "", fmt.Errorf("cannot encode AEM instances configuration: %w", err)
+		}
+		setConfigNode(root, []string{"instance", "config"}, &instancesNode)
+	}
+
+	if overrides != "" {
+		var overridesDoc yaml.Node
+		if err := yaml.Unmarshal([]byte(overrides), &overridesDoc); err != nil {
+			return "", fmt.Errorf("cannot parse AEM Compose configuration overrides: %w", err)
+		}
+		overridesRoot := configDocumentRoot(&overridesDoc)
+		if overridesRoot.Kind != yaml.MappingNode {
+			return "", fmt.Errorf("AEM Compose configuration overrides need to be a YAML mapping")
+		}
+		mergeConfigNodes(root, overridesRoot)
+	}
+
+	return encodeConfigDocument(&doc)
+}
+
+// composeConfigKnown checks if the AEM Compose configuration could be rendered already, i.e. no values it depends on are to be known after apply.
+// Unknown instance identifiers are rendered as empty ones, which would be falsely reported as duplicates.
+func composeConfigKnown(model InstanceResourceModel) bool {
+	if model.Compose.Config.IsUnknown() || model.Compose.Overrides.IsUnknown() {
+		return false
+	}
+	for _, instanceModel := range model.Compose.Instance {
+		if instanceModel.ID.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
+
+// configDocumentRoot returns the top-level node of the YAML document; an empty document gets an empty mapping.
+func configDocumentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if doc.Kind != yaml.DocumentNode {
+		return doc
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+	return doc.Content[0]
+}
+
+func configMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setConfigNode replaces the value at the key path, creating intermediate mappings if needed.
+func setConfigNode(mapping *yaml.Node, keys []string, value *yaml.Node) {
+	for i, key := range keys {
+		current := configMappingValue(mapping, key)
+		last := i == len(keys)-1
+		if current == nil || (!last && current.Kind != yaml.MappingNode) {
+			next := value
+			if !last {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			if current == nil {
+				mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, next)
+			} else {
+				*current = *next
+				next = current
+			}
+			mapping = next
+			continue
+		}
+		if last {
+			*current = *value
+		}
+		mapping = current
+	}
+}
+
+// mergeConfigNodes deep-merges the override mapping into the target one; mappings are merged, other values are replaced.
+func mergeConfigNodes(target *yaml.Node, override *yaml.Node) {
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key := override.Content[i]
+		value := override.Content[i+1]
+		current := configMappingValue(target, key.Value)
+		switch {
+		case current == nil:
+			target.Content = append(target.Content, key, value)
+		case current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeConfigNodes(current, value)
+		default:
+			comment := current.LineComment
+			*current = *value
+			if current.LineComment == "" {
+				current.LineComment = comment
+			}
+		}
+	}
+}
+
+// yamlSemanticEqual checks if both YAML documents have the same content regardless of formatting, comments and key order.
+func yamlSemanticEqual(a string, b string) (bool, error) {
+	var valueA, valueB any
+	if err := yaml.Unmarshal([]byte(a), &valueA); err != nil {
+		return false, err
+	}
+	if err := yaml.Unmarshal([]byte(b), &valueB); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(valueA, valueB), nil
+}
+
+// yamlChangedKeys lists the dotted key paths which differ between the YAML documents, prefixed with '+' (added), '-' (removed) or '~' (changed).
+func yamlChangedKeys(before string, after string) ([]string, error) {
+	var valueBefore, valueAfter any
+	if err := yaml.Unmarshal([]byte(before), &valueBefore); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal([]byte(after), &valueAfter); err != nil {
+		return nil, err
+	}
+	var changes []string
+	collectYAMLChanges(valueBefore, valueAfter, "", &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i][1:] < changes[j][1:] })
+	return changes, nil
+}
+
+func collectYAMLChanges(before any, after any, key string, changes *[]string) {
+	mapBefore, beforeIsMap := before.(map[string]any)
+	mapAfter, afterIsMap := after.(map[string]any)
+	if !beforeIsMap || !afterIsMap {
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, "~"+key)
+		}
+		return
+	}
+	for name, valueBefore := range mapBefore {
+		childKey := strings.TrimPrefix(key+"."+name, ".")
+		if valueAfter, exists := mapAfter[name]; exists {
+			collectYAMLChanges(valueBefore, valueAfter, childKey, changes)
+		} else {
+			*changes = append(*changes, "-"+childKey)
+		}
+	}
+	for name := range mapAfter {
+		if _, exists := mapBefore[name]; !exists {
+			*changes = append(*changes, "+"+strings.TrimPrefix(key+"."+name, "."))
+		}
+	}
+}
+
+// yamlSemanticPlanModifier keeps the prior value if the planned YAML has the same content, so that reformatting does not cause an update.
+// Otherwise, the changed keys are reported to make the plan readable.
+type yamlSemanticPlanModifier struct{}
+
+func (m yamlSemanticPlanModifier) Description(ctx context.Context) string {
+	return "Suppresses changes of YAML which does not differ semantically and reports changed keys otherwise."
+}
+
+func (m yamlSemanticPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m yamlSemanticPlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.StateValue.IsNull() || req.StateValue.IsUnknown() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+		return
+	}
+	before := req.StateValue.ValueString()
+	after := req.PlanValue.ValueString()
+	if before == after {
+		return
+	}
+	equal, err := yamlSemanticEqual(before, after)
+	if err != nil {
+		return // invalid YAML is reported by config validation
+	}
+	if equal {
+		resp.PlanValue = req.StateValue
+		return
+	}
+	changes, err := yamlChangedKeys(before, after)
+	if err != nil || len(changes) == 0 {
+		return
+	}
+	resp.Diagnostics.AddAttributeWarning(req.Path, "AEM Compose configuration changes", fmt.Sprintf("Changed keys:\n  %s", strings.Join(changes, "\n  ")))
+}
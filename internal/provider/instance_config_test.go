@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+func testComposeInstance(id string, httpURL string) InstanceComposeInstanceModel {
+	return InstanceComposeInstanceModel{
+		ID:         types.StringValue(id),
+		HTTPURL:    types.StringValue(httpURL),
+		User:       types.StringNull(),
+		Password:   types.StringNull(),
+		RunModes:   types.ListNull(types.StringType),
+		JVMOpts:    types.ListNull(types.StringType),
+		StartOpts:  types.ListNull(types.StringType),
+		EnvVars:    types.ListNull(types.StringType),
+		SecretVars: types.ListNull(types.StringType),
+		SlingProps: types.ListNull(types.StringType),
+	}
+}
+
+func testComposeModel(config string, overrides string, instances ...InstanceComposeInstanceModel) InstanceResourceModel {
+	model := InstanceResourceModel{}
+	model.Compose.Config = types.StringValue(config)
+	if overrides == "" {
+		model.Compose.Overrides = types.StringNull()
+	} else {
+		model.Compose.Overrides = types.StringValue(overrides)
+	}
+	model.Compose.Instance = instances
+	return model
+}
+
+func TestRenderComposeConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     InstanceResourceModel
+		want      string
+		wantError string
+	}{
+		{
+			name:  "base config returned as-is",
+			model: testComposeModel("# comment\nlog:\n  level: info\n", ""),
+			want:  "# comment\nlog:\n  level: info\n",
+		},
+		{
+			name: "instances replace the instance config",
+			model: testComposeModel("instance:\n  config:\n    local_old:\n      http_url: http://127.0.0.1:4502\n  check:\n    interval: 5s\n", "",
+				testComposeInstance("local_author", "http://127.0.0.1:4502"),
+			),
+			want: "instance:\n  config:\n    local_author:\n      active: true\n      http_url: http://127.0.0.1:4502\n  check:\n    interval: 5s\n",
+		},
+		{
+			name:  "instances create missing sections",
+			model: testComposeModel("", "", testComposeInstance("local_publish", "http://127.0.0.1:4503")),
+			want:  "instance:\n  config:\n    local_publish:\n      active: true\n      http_url: http://127.0.0.1:4503\n",
+		},
+		{
+			name:  "overrides are deep-merged",
+			model: testComposeModel("log:\n  level: info # verbosity\n  text_mode: true\n", "log:\n  level: debug\njava:\n  version_constraints: '>= 11'\n"),
+			want:  "log:\n  level: debug # verbosity\n  text_mode: true\njava:\n  version_constraints: '>= 11'\n",
+		},
+		{
+			name: "duplicated instance",
+			model: testComposeModel("", "",
+				testComposeInstance("local_author", "http://127.0.0.1:4502"),
+				testComposeInstance("local_author", "http://127.0.0.1:4503"),
+			),
+			wantError: "defined more than once",
+		},
+		{
+			name:      "base config not a mapping",
+			model:     testComposeModel("- a\n- b\n", "log:\n  level: debug\n"),
+			wantError: "needs to be a YAML mapping",
+		},
+		{
+			name:      "overrides not a mapping",
+			model:     testComposeModel("log:\n  level: info\n", "debug"),
+			wantError: "need to be a YAML mapping",
+		},
+		{
+			name:      "invalid overrides",
+			model:     testComposeModel("log:\n  level: info\n", "log: [unclosed"),
+			wantError: "cannot parse AEM Compose configuration overrides",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderComposeConfig(context.Background(), tt.model)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing '%s', got: %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderComposeConfig() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeConfigNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		override string
+		want     string
+	}{
+		{
+			name:     "new keys appended",
+			target:   "a: 1\n",
+			override: "b: 2\n",
+			want:     "a: 1\nb: 2\n",
+		},
+		{
+			name:     "nested mappings merged",
+			target:   "a:\n  x: 1\n  y: 2\n",
+			override: "a:\n  y: 3\n  z: 4\n",
+			want:     "a:\n  x: 1\n  y: 3\n  z: 4\n",
+		},
+		{
+			name:     "lists replaced",
+			target:   "a: [1, 2]\n",
+			override: "a: [3]\n",
+			want:     "a: [3]\n",
+		},
+		{
+			name:     "mapping replaced by scalar",
+			target:   "a:\n  x: 1\n",
+			override: "a: off\n",
+			want:     "a: off\n",
+		},
+		{
+			name:     "line comment kept",
+			target:   "a: 1 # important\n",
+			override: "a: 2\n",
+			want:     "a: 2 # important\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target, override yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.target), &target); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.override), &override); err != nil {
+				t.Fatal(err)
+			}
+			mergeConfigNodes(configDocumentRoot(&target), configDocumentRoot(&override))
+			got, err := encodeConfigDocument(&target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("mergeConfigNodes() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeConfigKnown(t *testing.T) {
+	unknownInstance := testComposeInstance("", "http://127.0.0.1:4502")
+	unknownInstance.ID = types.StringUnknown()
+	unknownConfig := testComposeModel("", "")
+	unknownConfig.Compose.Config = types.StringUnknown()
+
+	tests := []struct {
+		name  string
+		model InstanceResourceModel
+		want  bool
+	}{
+		{name: "all known", model: testComposeModel("", "", testComposeInstance("local_author", "http://127.0.0.1:4502")), want: true},
+		{name: "config unknown", model: unknownConfig, want: false},
+		{name: "instance identifiers unknown", model: testComposeModel("", "", unknownInstance, unknownInstance), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := composeConfigKnown(tt.model); got != tt.want {
+				t.Errorf("composeConfigKnown() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLSemanticEqual(t *testing.T) {
+	tests := []struct {
+		name      string
+		a         string
+		b         string
+		want      bool
+		wantError bool
+	}{
+		{name: "identical", a: "a: 1\n", b: "a: 1\n", want: true},
+		{name: "formatting, comments and key order ignored", a: "a: 1\nb:\n  c: [x, y]\n", b: "# comment\nb: {c: ['x', \"y\"]}\na: 1 # inline\n", want: true},
+		{name: "empty documents", a: "", b: "\n", want: true},
+		{name: "value changed", a: "a: 1\n", b: "a: 2\n", want: false},
+		{name: "type changed", a: "a: 1\n", b: "a: '1'\n", want: false},
+		{name: "list order matters", a: "a: [x, y]\n", b: "a: [y, x]\n", want: false},
+		{name: "invalid first document", a: "a: [", b: "a: 1\n", wantError: true},
+		{name: "invalid second document", a: "a: 1\n", b: "a: [", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yamlSemanticEqual(tt.a, tt.b)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("yamlSemanticEqual() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLChangedKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		before    string
+		after     string
+		want      []string
+		wantError bool
+	}{
+		{name: "no changes", before: "a: 1\n", after: "a: 1 # same\n", want: nil},
+		{name: "added, removed and changed keys sorted", before: "b: 1\nc:\n  d: x\n  e: y\n", after: "a: 1\nc:\n  d: z\n", want: []string{"+a", "-b", "~c.d", "-c.e"}},
+		{name: "mapping replaced by scalar", before: "a:\n  b: 1\n", after: "a: off\n", want: []string{"~a"}},
+		{name: "list changed", before: "a: [1]\n", after: "a: [1, 2]\n", want: []string{"~a"}},
+		{name: "whole document added", before: "", after: "a: 1\n", want: []string{"~"}},
+		{name: "invalid document", before: "a: 1\n", after: "a: [", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := yamlChangedKeys(tt.before, tt.after)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("yamlChangedKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/wttech/terraform-provider-aem/internal/utils"
+	"golang.org/x/exp/slices"
+	"os"
+)
+
+type InstanceFileModel struct {
+	Path          types.String `tfsdk:"path"`
+	Content       types.String `tfsdk:"content"`
+	ContentBase64 types.String `tfsdk:"content_base64"`
+	Source        types.String `tfsdk:"source"`
+	Vars          types.Map    `tfsdk:"vars"`
+	Mode          types.String `tfsdk:"mode"`
+	Owner         types.String `tfsdk:"owner"`
+	Group         types.String `tfsdk:"group"`
+	Preserve      types.Bool   `tfsdk:"preserve"`
+	Checksum      types.String `tfsdk:"checksum"`
+}
+
+// known checks if the file content could be determined before the apply.
+func (f InstanceFileModel) known() bool {
+	if f.Content.IsUnknown() || f.ContentBase64.IsUnknown() || f.Source.IsUnknown() || f.Vars.IsUnknown() {
+		return false
+	}
+	if !f.Source.IsNull() {
+		if _, err := os.Stat(f.Source.ValueString()); os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f InstanceFileModel) validate() error {
+	sources := 0
+	for _, value := range []types.String{f.Content, f.ContentBase64, f.Source} {
+		if !value.IsNull() {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("file '%s' needs to have exactly one of 'content', 'content_base64' or 'source' set", f.Path.ValueString())
+	}
+	if !f.Vars.IsNull() && f.Source.IsNull() {
+		return fmt.Errorf("file '%s' has 'vars' set which are only supported for 'source'", f.Path.ValueString())
+	}
+	return nil
+}
+
+// data returns the file content; the source file is rendered as a template only if variables are set.
+func (f InstanceFileModel) data(ctx context.Context) ([]byte, error) {
+	if !f.Content.IsNull() {
+		return []byte(f.Content.ValueString()), nil
+	}
+	if !f.ContentBase64.IsNull() {
+		data, err := base64.StdEncoding.DecodeString(f.ContentBase64.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode base64 content of file '%s': %w", f.Path.ValueString(), err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(f.Source.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read source of file '%s': %w", f.Path.ValueString(), err)
+	}
+	if f.Vars.IsNull() {
+		return data, nil
+	}
+	var vars map[string]string
+	f.Vars.ElementsAs(ctx, &vars, true)
+	templated, err := utils.TemplateString(string(data), vars)
+	if err != nil {
+		return nil, fmt.Errorf("unable to template source of file '%s': %w", f.Path.ValueString(), err)
+	}
+	return []byte(templated), nil
+}
+
+func (f InstanceFileModel) checksum(ctx context.Context) (types.String, error) {
+	if !f.known() {
+		return types.StringUnknown(), nil
+	}
+	data, err := f.data(ctx)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	hash := sha256.Sum256(data)
+	return types.StringValue(hex.EncodeToString(hash[:])), nil
+}
+
+// fileChecksums validates the file blocks and calculates checksums of their contents, so that content changes are shown in the plan.
+func (r *InstanceResource) fileChecksums(ctx context.Context, files []InstanceFileModel) error {
+	for i, file := range files {
+		if err := file.validate(); err != nil {
+			return err
+		}
+		checksum, err := file.checksum(ctx)
+		if err != nil {
+			return err
+		}
+		files[i].Checksum = checksum
+	}
+	return nil
+}
+
+func (ic *InstanceClient) writeFiles() error {
+	for _, file := range ic.data.File {
+		if err := ic.writeFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ic *InstanceClient) writeFile(file InstanceFileModel) error {
+	remotePath := file.Path.ValueString()
+	data, err := file.data(ic.ctx)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(os.TempDir(), "tf-provider-aem-*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create local temporary file to be copied to remote path '%s': %w", remotePath, err)
+	}
+	tmpPath := tmpFile.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	_, err = tmpFile.Write(data)
+	_ = tmpFile.Close()
+	if err != nil {
+		return fmt.Errorf("cannot write local temporary file to be copied to remote path '%s': %w", remotePath, err)
+	}
+
+	// changing ownership requires elevated permissions
+	owner := file.Owner.ValueString()
+	group := file.Group.ValueString()
+	if owner != "" || group != "" {
+		ic.cl.Sudo = true
+		defer func() { ic.cl.Sudo = false }()
+	}
+
+	// mode is applied before moving into place, so that restricted files are never readable by others
+	if err := ic.cl.FileCopyMode(tmpPath, remotePath, true, file.Mode.ValueString()); err != nil {
+		return fmt.Errorf("unable to write file '%s': %w", remotePath, err)
+	}
+	if owner != "" || group != "" {
+		ownership := owner
+		if group != "" {
+			ownership = fmt.Sprintf("%s:%s", owner, group)
+		}
+		if _, err := ic.cl.RunShellPurely(fmt.Sprintf("chown %s %s", ownership, remotePath)); err != nil {
+			return fmt.Errorf("unable to set ownership of file '%s': %w", remotePath, err)
+		}
+	}
+	tflog.Info(ic.ctx, fmt.Sprintf("Written file '%s'", remotePath))
+	return nil
+}
+
+// cleanupFiles deletes remote paths managed previously but no longer declared.
+func (ic *InstanceClient) cleanupFiles(previouslyManaged types.List) error {
+	var previousPaths, currentPaths, removedPaths []string
+	previouslyManaged.ElementsAs(ic.ctx, &previousPaths, true)
+	ic.data.FilesManaged.ElementsAs(ic.ctx, &currentPaths, true)
+	for _, path := range previousPaths {
+		if !slices.Contains(currentPaths, path) {
+			removedPaths = append(removedPaths, path)
+		}
+	}
+	return ic.deleteFiles(removedPaths)
+}
+
+// deleteManagedFiles deletes all remote paths managed by the provider.
+func (ic *InstanceClient) deleteManagedFiles() error {
+	var paths []string
+	ic.data.FilesManaged.ElementsAs(ic.ctx, &paths, true)
+	return ic.deleteFiles(paths)
+}
+
+func (ic *InstanceClient) deleteFiles(paths []string) error {
+	if !ic.data.FilesCleanup.ValueBool() || len(paths) == 0 {
+		return nil
+	}
+
+	// files could be written with changed ownership
+	ic.cl.Sudo = true
+	defer func() { ic.cl.Sudo = false }()
+
+	for _, path := range paths {
+		if err := ic.cl.PathDelete(path); err != nil {
+			return fmt.Errorf("unable to delete file '%s': %w", path, err)
+		}
+		tflog.Info(ic.ctx, fmt.Sprintf("Deleted file '%s'", path))
+	}
+	return nil
+}
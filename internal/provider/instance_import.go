@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/spf13/cast"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// instanceImportedKey marks in the private state that the resource was imported and not yet applied.
+const instanceImportedKey = "imported"
+
+// InstanceImportSpec describes an existing AEM deployment to be imported.
+type InstanceImportSpec struct {
+	Host   string `json:"host"`
+	Client struct {
+		Type        string            `json:"type"`
+		Settings    map[string]string `json:"settings"`
+		Credentials map[string]string `json:"credentials"`
+	} `json:"client"`
+	DataDir        string `json:"data_dir"`
+	WorkDir        string `json:"work_dir"`
+	ComposeVersion string `json:"compose_version"`
+}
+
+// parseInstanceImportID accepts a JSON spec or a URL like 'ssh://user@host:22/mnt/aemc' or 'aws-ssm://i-0123456789abcdef0/mnt/aemc?region=eu-central-1'.
+// The URL path is the data directory and query parameters are passed as client settings.
+func parseInstanceImportID(id string) (InstanceImportSpec, error) {
+	var spec InstanceImportSpec
+	if strings.HasPrefix(strings.TrimSpace(id), "{") {
+		if err := json.Unmarshal([]byte(id), &spec); err != nil {
+			return spec, fmt.Errorf("cannot parse import spec as JSON: %w", err)
+		}
+		return spec, nil
+	}
+
+	location, err := url.Parse(id)
+	if err != nil {
+		return spec, fmt.Errorf("cannot parse import ID as URL: %w", err)
+	}
+	if location.Scheme == "" || location.Hostname() == "" {
+		return spec, fmt.Errorf("import ID '%s' needs to be a JSON spec or URL in format '<type>://[<user>@]<target>[:<port>]/<data_dir>'", id)
+	}
+	spec.Client.Type = location.Scheme
+	spec.Client.Settings = map[string]string{}
+	switch location.Scheme {
+	case "ssh":
+		spec.Client.Settings["host"] = location.Hostname()
+		if location.Port() != "" {
+			spec.Client.Settings["port"] = location.Port()
+		}
+	case "aws-ssm", "aws-eic":
+		spec.Client.Settings["instance_id"] = location.Hostname()
+	default:
+		return spec, fmt.Errorf("import is not supported for client type '%s'", location.Scheme)
+	}
+	if location.User != nil && location.User.Username() != "" {
+		spec.Client.Settings["user"] = location.User.Username()
+	}
+	for name, values := range location.Query() {
+		if len(values) > 0 {
+			spec.Client.Settings[name] = values[0]
+		}
+	}
+	if dataDir := strings.TrimSuffix(location.Path, "/"); dataDir != "" {
+		spec.DataDir = dataDir
+	}
+	return spec, nil
+}
+
+func (r *InstanceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	spec, err := parseInstanceImportID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid AEM instance import ID", fmt.Sprintf("%s", err))
+		return
+	}
+
+	tflog.Info(ctx, "Started importing AEM instance resource")
+
+	model := r.importModel(ctx, spec)
+	host, err := r.host(model)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("host"), "Unknown AEM instance host", fmt.Sprintf("%s", err))
+		return
+	}
+	r.applyDefaults(&model, model, host)
+	clientModel := r.clientModel(model)
+	if clientModel.Type.IsNull() {
+		resp.Diagnostics.AddError("Missing AEM client type", "Client type needs to be set in the import ID, host or provider level.")
+		return
+	}
+
+	ic, err := r.client(ctx, model, cast.ToDuration(clientModel.StateTimeout.ValueString()))
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to connect to AEM instance", fmt.Sprintf("%s", err))
+		return
+	}
+	defer func(ic *InstanceClient) {
+		err := ic.Close()
+		if err != nil {
+			resp.Diagnostics.AddWarning("Unable to disconnect from AEM instance", fmt.Sprintf("%s", err))
+		}
+	}(ic)
+
+	if err := ic.readImportedDeployment(); err != nil {
+		resp.Diagnostics.AddError("Unable to read AEM instance deployment", fmt.Sprintf("%s", err))
+		return
+	}
+	status, err := ic.ReadStatus()
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to read AEM instance status", fmt.Sprintf("%s", err))
+		return
+	}
+	model = ic.data
+	resp.Diagnostics.Append(r.fillModelWithStatus(ctx, &model, status)...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &model)...)
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, instanceImportedKey, []byte("true"))...)
+
+	tflog.Info(ctx, "Finished importing AEM instance resource")
+}
+
+// importModel builds the model from the import spec; attributes which cannot be read from the machine are set to their defaults.
+func (r *InstanceResource) importModel(ctx context.Context, spec InstanceImportSpec) InstanceResourceModel {
+	model := r.newModel()
+	if spec.Host != "" {
+		model.Host = types.StringValue(spec.Host)
+	}
+	if spec.Client.Type != "" || len(spec.Client.Settings) > 0 || len(spec.Client.Credentials) > 0 {
+		clientModel := InstanceClientModel{}
+		if spec.Client.Type != "" {
+			clientModel.Type = types.StringValue(spec.Client.Type)
+		}
+		clientModel.Settings, _ = types.MapValueFrom(ctx, types.StringType, spec.Client.Settings)
+		if len(spec.Client.Credentials) > 0 {
+			clientModel.Credentials, _ = types.MapValueFrom(ctx, types.StringType, spec.Client.Credentials)
+		}
+		model.Client = &clientModel
+	}
+	if spec.DataDir != "" {
+		model.System.DataDir = types.StringValue(spec.DataDir)
+	}
+	if spec.WorkDir != "" {
+		model.System.WorkDir = types.StringValue(spec.WorkDir)
+	}
+	if spec.ComposeVersion != "" {
+		model.Compose.Version = types.StringValue(spec.ComposeVersion)
+	}
+
+	model.Files = types.MapValueMust(types.StringType, map[string]attr.Value{})
+	model.FilesChecksums = types.MapValueMust(types.StringType, map[string]attr.Value{})
+	model.FilesCleanup = types.BoolValue(false)
+	model.FilesManaged = types.ListValueMust(types.StringType, []attr.Value{})
+	model.System.Env = types.MapValueMust(types.StringType, map[string]attr.Value{})
+	model.System.SecretEnv = types.MapNull(types.StringType)
+	model.System.ServiceConfig = types.StringValue(instance.ServiceConf)
+	model.System.User = types.StringValue("")
+	model.System.Bootstrap = InstanceScript{Inline: types.ListNull(types.StringType), Script: types.StringNull()}
+	model.Compose.Download = types.BoolValue(true)
+	model.Compose.LocalDownload = types.BoolValue(false)
+	model.Compose.PruneVersions = types.BoolValue(false)
+	model.Compose.Config = types.StringValue(instance.ConfigYML)
+	model.Compose.Passwords = types.MapNull(types.StringType)
+	model.Compose.SecretVars = types.MapNull(types.StringType)
+	model.Compose.Create = InstanceScript{Inline: instanceScriptSchemaInlineValue(instance.CreateScriptInline), Script: types.StringNull()}
+	model.Compose.Configure = InstanceScript{Inline: instanceScriptSchemaInlineValue(instance.LaunchScriptInline), Script: types.StringNull()}
+	model.Compose.Delete = InstanceScript{Inline: instanceScriptSchemaInlineValue(instance.DeleteScriptInline), Script: types.StringNull()}
+	return model
+}
+
+const requiresReplaceUnlessImportedDescription = "Instance recreation is forced if changed, unless the resource was imported and not yet applied (the scripts which created the imported instances are unknown)."
+
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+func instanceImported(ctx context.Context, private privateState) bool {
+	imported, _ := private.GetKey(ctx, instanceImportedKey)
+	return string(imported) == "true"
+}
+
+func requiresReplaceUnlessImportedList(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+	resp.RequiresReplace = !instanceImported(ctx, req.Private)
+}
+
+func requiresReplaceUnlessImportedString(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+	resp.RequiresReplace = !instanceImported(ctx, req.Private)
+}
+
+var serviceUserRegex = regexp.MustCompile(`(?m)^User=(.*)$`)
+
+// readImportedDeployment reads the settings deployed on the machine which are not covered by drift detection.
+func (ic *InstanceClient) readImportedDeployment() error {
+	exists, err := ic.deploymentExists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("AEM Compose configuration file '%s' not found", ic.configFilePath())
+	}
+	if installedVersion, err := ic.ReadComposeVersion(); err != nil {
+		return err
+	} else if installedVersion != "" {
+		ic.data.Compose.Version = types.StringValue(installedVersion)
+	}
+	serviceConfig, err := ic.readFileIfExists(ic.serviceFilePath())
+	if err != nil {
+		return err
+	}
+	if match := serviceUserRegex.FindStringSubmatch(serviceConfig); match != nil {
+		if user := strings.TrimSpace(match[1]); user != ic.cl.Connection().User() {
+			ic.data.System.User = types.StringValue(user)
+		}
+	}
+	return ic.readDeployment()
+}
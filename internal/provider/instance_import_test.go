@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInstanceImportID(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		wantType     string
+		wantSettings map[string]string
+		wantHost     string
+		wantDataDir  string
+		wantError    string
+	}{
+		{
+			name:         "ssh with user, port and data directory",
+			id:           "ssh://ec2-user@10.0.0.10:2222/mnt/aemc",
+			wantType:     "ssh",
+			wantSettings: map[string]string{"host": "10.0.0.10", "port": "2222", "user": "ec2-user"},
+			wantDataDir:  "/mnt/aemc",
+		},
+		{
+			name:         "ssh without data directory",
+			id:           "ssh://10.0.0.10",
+			wantType:     "ssh",
+			wantSettings: map[string]string{"host": "10.0.0.10"},
+		},
+		{
+			name:         "aws-ssm with query settings",
+			id:           "aws-ssm://i-0123456789abcdef0/data/aemc/?region=eu-central-1&profile=dev",
+			wantType:     "aws-ssm",
+			wantSettings: map[string]string{"instance_id": "i-0123456789abcdef0", "region": "eu-central-1", "profile": "dev"},
+			wantDataDir:  "/data/aemc",
+		},
+		{
+			name:         "aws-eic with user",
+			id:           "aws-eic://ec2-user@i-0123456789abcdef0/mnt/aemc",
+			wantType:     "aws-eic",
+			wantSettings: map[string]string{"instance_id": "i-0123456789abcdef0", "user": "ec2-user"},
+			wantDataDir:  "/mnt/aemc",
+		},
+		{
+			name:         "JSON spec",
+			id:           ` {"host": "author-1", "client": {"type": "ssh", "settings": {"host": "10.0.0.10"}}, "data_dir": "/mnt/aemc"}`,
+			wantType:     "ssh",
+			wantSettings: map[string]string{"host": "10.0.0.10"},
+			wantHost:     "author-1",
+			wantDataDir:  "/mnt/aemc",
+		},
+		{
+			name:      "invalid JSON spec",
+			id:        `{"client": `,
+			wantError: "cannot parse import spec as JSON",
+		},
+		{
+			name:      "missing scheme",
+			id:        "10.0.0.10/mnt/aemc",
+			wantError: "needs to be a JSON spec or URL",
+		},
+		{
+			name:      "missing target",
+			id:        "ssh:///mnt/aemc",
+			wantError: "needs to be a JSON spec or URL",
+		},
+		{
+			name:      "unsupported client type",
+			id:        "ftp://10.0.0.10/mnt/aemc",
+			wantError: "not supported for client type 'ftp'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseInstanceImportID(tt.id)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing '%s', got: %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if spec.Client.Type != tt.wantType {
+				t.Errorf("client type = '%s', want '%s'", spec.Client.Type, tt.wantType)
+			}
+			if !reflect.DeepEqual(spec.Client.Settings, tt.wantSettings) {
+				t.Errorf("client settings = %v, want %v", spec.Client.Settings, tt.wantSettings)
+			}
+			if spec.Host != tt.wantHost {
+				t.Errorf("host = '%s', want '%s'", spec.Host, tt.wantHost)
+			}
+			if spec.DataDir != tt.wantDataDir {
+				t.Errorf("data directory = '%s', want '%s'", spec.DataDir, tt.wantDataDir)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -17,35 +18,69 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/wttech/terraform-provider-aem/internal/client"
 	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+	"github.com/wttech/terraform-provider-aem/internal/utils"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
 type InstanceResourceModel struct {
-	Client struct {
-		Type          types.String `tfsdk:"type"`
-		Settings      types.Map    `tfsdk:"settings"`
-		Credentials   types.Map    `tfsdk:"credentials"`
-		ActionTimeout types.String `tfsdk:"action_timeout"`
-		StateTimeout  types.String `tfsdk:"state_timeout"`
-	} `tfsdk:"client"`
-	Files  types.Map `tfsdk:"files"`
-	System struct {
+	Client *InstanceClientModel `tfsdk:"client"`
+	Host   types.String         `tfsdk:"host"`
+	Facts  types.Map            `tfsdk:"facts"`
+	Files  types.Map            `tfsdk:"files"`
+
+	FilesChecksums types.Map                   `tfsdk:"files_checksums"`
+	FilesPreserve  types.List                  `tfsdk:"files_preserve"`
+	FilesCleanup   types.Bool                  `tfsdk:"files_cleanup"`
+	FilesManaged   types.List                  `tfsdk:"files_managed"`
+	File           []InstanceFileModel         `tfsdk:"file"`
+	Artifact       []InstanceArtifactModel     `tfsdk:"artifact"`
+	ArtifactCache  *InstanceArtifactCacheModel `tfsdk:"artifact_cache"`
+	System         struct {
 		DataDir       types.String   `tfsdk:"data_dir"`
 		WorkDir       types.String   `tfsdk:"work_dir"`
 		Env           types.Map      `tfsdk:"env"`
+		SecretEnv     types.Map      `tfsdk:"secret_env"`
 		ServiceConfig types.String   `tfsdk:"service_config"`
 		User          types.String   `tfsdk:"user"`
 		Bootstrap     InstanceScript `tfsdk:"bootstrap"`
 	} `tfsdk:"system"`
 	Compose struct {
-		Download  types.Bool     `tfsdk:"download"`
-		Version   types.String   `tfsdk:"version"`
-		Config    types.String   `tfsdk:"config"`
-		Create    InstanceScript `tfsdk:"create"`
-		Configure InstanceScript `tfsdk:"configure"`
-		Delete    InstanceScript `tfsdk:"delete"`
+		Download           types.Bool                     `tfsdk:"download"`
+		Version            types.String                   `tfsdk:"version"`
+		CLISource          types.String                   `tfsdk:"cli_source"`
+		CLIChecksum        types.String                   `tfsdk:"cli_checksum"`
+		CLIChecksumsSource types.String                   `tfsdk:"cli_checksums_source"`
+		LocalDownload      types.Bool                     `tfsdk:"local_download"`
+		CacheDir           types.String                   `tfsdk:"cache_dir"`
+		PruneVersions      types.Bool                     `tfsdk:"prune_versions"`
+		Config             types.String                   `tfsdk:"config"`
+		Instance           []InstanceComposeInstanceModel `tfsdk:"instance"`
+		Overrides          types.String                   `tfsdk:"overrides"`
+		Passwords          types.Map                      `tfsdk:"passwords"`
+		SecretVars         types.Map                      `tfsdk:"secret_vars"`
+		SecretsVersion     types.String                   `tfsdk:"secrets_version"`
+		Create             InstanceScript                 `tfsdk:"create"`
+		Configure          InstanceScript                 `tfsdk:"configure"`
+		Delete             InstanceScript                 `tfsdk:"delete"`
 	} `tfsdk:"compose"`
-	Instances types.List `tfsdk:"instances"`
+	Readiness     *InstanceReadinessModel `tfsdk:"readiness"`
+	Instances     types.List              `tfsdk:"instances"`
+	InstancesByID types.Map               `tfsdk:"instances_by_id"`
+}
+
+type InstanceClientModel struct {
+	Type          types.String `tfsdk:"type"`
+	Settings      types.Map    `tfsdk:"settings"`
+	Credentials   types.Map    `tfsdk:"credentials"`
+	ActionTimeout types.String `tfsdk:"action_timeout"`
+	StateTimeout  types.String `tfsdk:"state_timeout"`
 }
 
 type InstanceScript struct {
@@ -82,23 +117,35 @@ func instanceScriptSchemaInlineValue(inline []string) basetypes.ListValue {
 }
 
 type InstanceStatusItemModel struct {
-	ID         types.String `tfsdk:"id"`
-	URL        types.String `tfsdk:"url"`
-	AemVersion types.String `tfsdk:"aem_version"`
-	Dir        types.String `tfsdk:"dir"`
-	Attributes types.List   `tfsdk:"attributes"`
-	RunModes   types.List   `tfsdk:"run_modes"`
+	ID           types.String `tfsdk:"id"`
+	URL          types.String `tfsdk:"url"`
+	AemVersion   types.String `tfsdk:"aem_version"`
+	Dir          types.String `tfsdk:"dir"`
+	Attributes   types.List   `tfsdk:"attributes"`
+	RunModes     types.List   `tfsdk:"run_modes"`
+	HealthChecks types.List   `tfsdk:"health_checks"`
+	Running      types.Bool   `tfsdk:"running"`
+	UpToDate     types.Bool   `tfsdk:"up_to_date"`
+	Healthy      types.Bool   `tfsdk:"healthy"`
+	StartTime    types.String `tfsdk:"start_time"`
+	Local        types.Bool   `tfsdk:"local"`
 }
 
 // fix for https://github.com/hashicorp/terraform-plugin-framework/issues/713
 func (o InstanceStatusItemModel) attrTypes() map[string]attr.Type {
 	return map[string]attr.Type{
-		"id":          types.StringType,
-		"url":         types.StringType,
-		"aem_version": types.StringType,
-		"dir":         types.StringType,
-		"attributes":  types.ListType{ElemType: types.StringType},
-		"run_modes":   types.ListType{ElemType: types.StringType},
+		"id":            types.StringType,
+		"url":           types.StringType,
+		"aem_version":   types.StringType,
+		"dir":           types.StringType,
+		"attributes":    types.ListType{ElemType: types.StringType},
+		"run_modes":     types.ListType{ElemType: types.StringType},
+		"health_checks": types.ListType{ElemType: types.StringType},
+		"running":       types.BoolType,
+		"up_to_date":    types.BoolType,
+		"healthy":       types.BoolType,
+		"start_time":    types.StringType,
+		"local":         types.BoolType,
 	}
 }
 
@@ -107,34 +154,35 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 		MarkdownDescription: instance.DescriptionMD,
 		Blocks: map[string]schema.Block{
 			"client": schema.SingleNestedBlock{
-				MarkdownDescription: "Connection settings used to access the machine on which the AEM instance will be running.",
+				MarkdownDescription: "Connection settings used to access the machine on which the AEM instance will be running. May be omitted if the host or provider-level defaults are used.",
 				Attributes: map[string]schema.Attribute{
 					"type": schema.StringAttribute{
-						MarkdownDescription: "Type of connection to use to connect to the machine on which AEM instance will be running.",
-						Required:            true,
+						MarkdownDescription: "Type of connection to use to connect to the machine on which AEM instance will be running. Inherited from the provider if not set.",
+						Optional:            true,
+						Computed:            true,
 					},
 					"settings": schema.MapAttribute{
-						MarkdownDescription: "Settings for the connection type",
+						MarkdownDescription: "Settings for the connection type. Merged with the ones defined on the provider level.",
 						ElementType:         types.StringType,
-						Required:            true,
+						Optional:            true,
+						Computed:            true,
 					},
 					"credentials": schema.MapAttribute{
-						MarkdownDescription: "Credentials for the connection type",
+						MarkdownDescription: "Credentials for the connection type. Merged with the ones defined on the provider level.",
 						ElementType:         types.StringType,
 						Optional:            true,
+						Computed:            true,
 						Sensitive:           true,
 					},
 					"action_timeout": schema.StringAttribute{
-						MarkdownDescription: "Used when trying to connect to the AEM instance machine (often right after creating it). Need to be enough long because various types of connections (like AWS SSM or SSH) may need some time to boot up the agent.",
+						MarkdownDescription: fmt.Sprintf("Used when trying to connect to the AEM instance machine (often right after creating it). Need to be enough long because various types of connections (like AWS SSM or SSH) may need some time to boot up the agent. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.ActionTimeoutDefault),
 						Optional:            true,
 						Computed:            true,
-						Default:             stringdefault.StaticString("10m"),
 					},
 					"state_timeout": schema.StringAttribute{
-						MarkdownDescription: "Used when reading the AEM instance state when determining the plan.",
+						MarkdownDescription: fmt.Sprintf("Used when reading the AEM instance state when determining the plan. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.StateTimeoutDefault),
 						Optional:            true,
 						Computed:            true,
-						Default:             stringdefault.StaticString("30s"),
 					},
 				},
 			},
@@ -157,20 +205,17 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 						},
 					},
 					"data_dir": schema.StringAttribute{
-						MarkdownDescription: "Remote root path in which AEM Compose files and unpacked AEM instances will be stored.",
+						MarkdownDescription: fmt.Sprintf("Remote root path in which AEM Compose files and unpacked AEM instances will be stored. Instance recreation is forced if changed. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.DataDirDefault),
 						Computed:            true,
 						Optional:            true,
-						Default:             stringdefault.StaticString("/mnt/aemc"),
-						PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
 					},
 					"work_dir": schema.StringAttribute{
-						MarkdownDescription: "Remote root path where provider-related files will be stored.",
+						MarkdownDescription: fmt.Sprintf("Remote root path where provider-related files will be stored. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.WorkDirDefault),
 						Computed:            true,
 						Optional:            true,
-						Default:             stringdefault.StaticString("/tmp/aemc"),
 					},
 					"service_config": schema.StringAttribute{
-						MarkdownDescription: "Contents of the AEM system service definition file (systemd).",
+						MarkdownDescription: "Contents of the AEM system service definition file (systemd). Supports template variables '[[.DATA_DIR]]', '[[.USER]]' and '[[.SECRET_ENV_FILE]]'.",
 						Optional:            true,
 						Computed:            true,
 						Default:             stringdefault.StaticString(instance.ServiceConf),
@@ -189,28 +234,218 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 						Optional:            true,
 						Default:             mapdefault.StaticValue(types.MapValueMust(types.StringType, map[string]attr.Value{})),
 					},
+					"secret_env": schema.MapAttribute{
+						MarkdownDescription: "Sensitive environment variables for AEM instances. Written to a separate file readable only by the service user and loaded only by the system service via 'EnvironmentFile' (the service definition needs to refer to '[[.SECRET_ENV_FILE]]'), so they are neither exposed in profile scripts nor passed to commands run by the provider. Changing them restarts the system service.",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
+			"artifact": schema.ListNestedBlock{
+				MarkdownDescription: "Artifact (e.g. AEM SDK, quickstart JAR, license or service pack) to be fetched on the machine before AEM instance(s) are created. Downloaded again only if missing or not matching the checksum.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"source": schema.StringAttribute{
+							MarkdownDescription: "Location of the artifact. Supports HTTP(S) URLs, S3 URLs like 's3://bucket/key' (requires AWS CLI on the machine) and local paths uploaded from the machine running Terraform.",
+							Required:            true,
+						},
+						"destination": schema.StringAttribute{
+							MarkdownDescription: "Remote path of the artifact. Relative paths are resolved against the data directory, e.g. 'aem/home/lib/cq-quickstart.jar'.",
+							Required:            true,
+						},
+						"checksum": schema.StringAttribute{
+							MarkdownDescription: "Expected SHA-256 checksum of the artifact. If set, the artifact is verified after fetching and fetched again when the one already present does not match. Otherwise, an already present artifact is never fetched again.",
+							Optional:            true,
+						},
+						"retries": schema.Int64Attribute{
+							MarkdownDescription: fmt.Sprintf("Number of attempts to fetch the artifact. Defaults to '%d'.", instance.ArtifactRetriesDefault),
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"artifact_cache": schema.SingleNestedBlock{
+				MarkdownDescription: "Cache on the machine for artifacts with checksums set. Cached artifacts are linked or copied into place instead of being fetched again, e.g. when AEM instance(s) are recreated. Kept outside of the data directory so it survives resource deletion.",
+				Attributes: map[string]schema.Attribute{
+					"dir": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Remote path of the cache directory. Defaults to '%s'.", instance.ArtifactCacheDirDefault),
+						Optional:            true,
+					},
+					"max_size_mb": schema.Int64Attribute{
+						MarkdownDescription: fmt.Sprintf("Maximum total size of cached artifacts in megabytes. Least recently used artifacts are evicted when exceeded. Defaults to '%d'.", instance.ArtifactCacheMaxSizeMBDefault),
+						Optional:            true,
+					},
+				},
+			},
+			"file": schema.ListNestedBlock{
+				MarkdownDescription: "File to be written on the machine with contents defined inline or rendered from a local template. Written before AEM instance(s) are created or launched.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							MarkdownDescription: "Remote path of the file.",
+							Required:            true,
+						},
+						"content": schema.StringAttribute{
+							MarkdownDescription: "Text content of the file. Treated as sensitive, as files often hold secrets (e.g. licenses, keys).",
+							Optional:            true,
+							Sensitive:           true,
+						},
+						"content_base64": schema.StringAttribute{
+							MarkdownDescription: "Binary content of the file encoded in base64. Treated as sensitive.",
+							Optional:            true,
+							Sensitive:           true,
+						},
+						"source": schema.StringAttribute{
+							MarkdownDescription: "Local path of the file to be copied. Rendered as a template (with '[[' and ']]' delimiters) if 'vars' are set.",
+							Optional:            true,
+						},
+						"vars": schema.MapAttribute{
+							MarkdownDescription: "Variables available in the source template, e.g. '[[.NAME]]'.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
+						"mode": schema.StringAttribute{
+							MarkdownDescription: "Remote file permissions in octal notation, e.g. '0644'.",
+							Optional:            true,
+						},
+						"owner": schema.StringAttribute{
+							MarkdownDescription: "Remote file owner. Setting it requires sudo permissions.",
+							Optional:            true,
+						},
+						"group": schema.StringAttribute{
+							MarkdownDescription: "Remote file group. Setting it requires sudo permissions.",
+							Optional:            true,
+						},
+						"preserve": schema.BoolAttribute{
+							MarkdownDescription: "Never delete the file by the provider, even if no longer declared.",
+							Optional:            true,
+						},
+						"checksum": schema.StringAttribute{
+							MarkdownDescription: "SHA-256 checksum of the file content. Changing the content causes the file to be written again.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"readiness": schema.SingleNestedBlock{
+				MarkdownDescription: "Criteria awaited after launching AEM instance(s). If defined, the apply completes only when all of them are met or fails with the last observed status when the timeout is exceeded.",
+				Attributes: map[string]schema.Attribute{
+					"instances": schema.ListAttribute{
+						MarkdownDescription: "Identifiers of AEM instances to be checked. By default, all instances stored on the machine.",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"attributes": schema.ListAttribute{
+						MarkdownDescription: fmt.Sprintf("Status attributes which all checked instances need to report (e.g. 'running', 'up-to-date'). Defaults to '%s'.", strings.Join(instance.ReadinessAttributesDefault, "', '")),
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"healthy": schema.BoolAttribute{
+						MarkdownDescription: "Require no health check issues to be reported by checked instances.",
+						Optional:            true,
+					},
+					"bundles": schema.ListAttribute{
+						MarkdownDescription: "Symbolic names of OSGi bundles which need to be active on checked instances.",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"paths": schema.MapAttribute{
+						MarkdownDescription: "Paths (e.g. '/libs/granite/core/content/login.html') requested on checked instances mapped to the expected HTTP status codes.",
+						ElementType:         types.Int64Type,
+						Optional:            true,
+					},
+					"user": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("User used to authenticate requests when checking bundles and paths. Defaults to the user of the checked instance block or '%s'.", instance.ReadinessUserDefault),
+						Optional:            true,
+					},
+					"password": schema.StringAttribute{
+						MarkdownDescription: "Password used to authenticate requests when checking bundles and paths. Write-only (requires Terraform 1.11 or later), so never stored in plan or state. Defaults to the password of the checked instance set in 'compose.passwords' or its instance block; requests are not authenticated if none is set.",
+						Optional:            true,
+						Sensitive:           true,
+						WriteOnly:           true,
+					},
+					"timeout": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Maximum time to wait until the criteria are met. Defaults to '%s'.", instance.ReadinessTimeoutDefault),
+						Optional:            true,
+					},
+					"interval": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Time between subsequent checks. Defaults to '%s'.", instance.ReadinessIntervalDefault),
+						Optional:            true,
+					},
 				},
 			},
 			"compose": schema.SingleNestedBlock{
 				MarkdownDescription: "AEM Compose CLI configuration. See [documentation](https://github.com/wttech/aemc#configuration).",
 				Attributes: map[string]schema.Attribute{
 					"download": schema.BoolAttribute{
-						MarkdownDescription: "Toggle automatic AEM Compose CLI wrapper download. If set to false, assume the wrapper is present in the data directory.",
+						MarkdownDescription: "Toggle automatic AEM Compose CLI installation. If set to false, assume the wrapper is present in the data directory.",
 						Computed:            true,
 						Optional:            true,
 						Default:             booldefault.StaticBool(true),
 					},
 					"version": schema.StringAttribute{
-						MarkdownDescription: "Version of AEM Compose tool to use on remote machine.",
+						MarkdownDescription: fmt.Sprintf("Version of AEM Compose tool to use on remote machine. Inherited from the provider if not set, otherwise defaults to '%s'. The version installed on the machine is read back, so changing it is shown in the plan and upgrades the tool.", instance.ComposeVersionDefault),
 						Computed:            true,
 						Optional:            true,
-						Default:             stringdefault.StaticString("1.6.12"),
+					},
+					"cli_source": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("URL or local path (on the Terraform host) of the AEM Compose CLI release archive ('.tar.gz') or binary. Supports placeholders '[[.VERSION]]', '[[.OS]]' and '[[.ARCH]]' resolved for the remote machine. The CLI is installed by the provider and the wrapper script is generated, so nothing is downloaded by the wrapper itself. Defaults to '%s'.", instance.ComposeCLISourceDefault),
+						Optional:            true,
+					},
+					"cli_checksum": schema.StringAttribute{
+						MarkdownDescription: "Expected SHA-256 checksum of the AEM Compose CLI release archive (or binary) for the remote machine OS and architecture. If not set, it is looked up in 'cli_checksums_source'. The apply fails if the downloaded file does not match.",
+						Optional:            true,
+					},
+					"cli_checksums_source": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("URL or local path (on the Terraform host) of the checksums file published with the AEM Compose CLI release (lines in the 'sha256sum' format), used to verify the CLI when 'cli_checksum' is not set. Supports placeholder '[[.VERSION]]'. The apply fails if the checksum of the CLI file cannot be determined. Defaults to '%s'.", instance.ComposeCLIChecksumsSourceDefault),
+						Optional:            true,
+					},
+					"local_download": schema.BoolAttribute{
+						MarkdownDescription: "Download URL sources of the CLI and its checksums on the Terraform host and upload them to the remote machine instead of downloading them on the machine.",
+						Computed:            true,
+						Optional:            true,
+						Default:             booldefault.StaticBool(false),
+					},
+					"cache_dir": schema.StringAttribute{
+						MarkdownDescription: "Directory on the Terraform host for caching files downloaded locally. Defaults to 'terraform-provider-aem' in the user cache directory.",
+						Optional:            true,
+					},
+					"prune_versions": schema.BoolAttribute{
+						MarkdownDescription: "Toggle removal of AEM Compose CLI versions other than the configured one from the data directory after upgrading.",
+						Computed:            true,
+						Optional:            true,
+						Default:             booldefault.StaticBool(false),
 					},
 					"config": schema.StringAttribute{
-						MarkdownDescription: "Contents of the AEM Compose YML configuration file.",
+						MarkdownDescription: "Contents of the AEM Compose YML configuration file. Used as a base when 'instance' blocks or 'overrides' are set.",
 						Computed:            true,
 						Optional:            true,
 						Default:             stringdefault.StaticString(instance.ConfigYML),
+						PlanModifiers:       []planmodifier.String{yamlSemanticPlanModifier{}},
+					},
+					"overrides": schema.StringAttribute{
+						MarkdownDescription: "YAML deep-merged over the AEM Compose configuration, e.g. `yamlencode({ instance = { check = { await_started = { timeout = \"45m\" } } } })`. Applied after 'instance' blocks.",
+						Optional:            true,
+						PlanModifiers:       []planmodifier.String{yamlSemanticPlanModifier{}},
+					},
+					"passwords": schema.MapAttribute{
+						MarkdownDescription: "Passwords of AEM instances keyed by their identifiers. Write-only (requires Terraform 1.11 or later), so never stored in plan or state. Injected into the AEM Compose configuration file written on the machine only (readable by its owner only). Change 'secrets_version' to apply changed values.",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Sensitive:           true,
+						WriteOnly:           true,
+					},
+					"secret_vars": schema.MapAttribute{
+						MarkdownDescription: "Secret variables passed to all AEM instances keyed by their names. Write-only (requires Terraform 1.11 or later), so never stored in plan or state. Injected into the AEM Compose configuration file written on the machine only (readable by its owner only). Change 'secrets_version' to apply changed values.",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Sensitive:           true,
+						WriteOnly:           true,
+					},
+					"secrets_version": schema.StringAttribute{
+						MarkdownDescription: "Arbitrary version of the write-only secrets ('passwords', 'secret_vars' and instance 'password' and 'secret_vars'). As changes of write-only values are not detected, change it to write the secrets again.",
+						Optional:            true,
 					},
 					"create": schema.SingleNestedAttribute{
 						MarkdownDescription: "Script(s) for creating an instance or restoring it from a backup. Typically customized to provide AEM library files (quickstart.jar, license.properties, service packs) from alternative sources (e.g., AWS S3, Azure Blob Storage). Instance recreation is forced if changed.",
@@ -224,12 +459,12 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 								Optional:            true,
 								Computed:            true,
 								Default:             listdefault.StaticValue(instanceScriptSchemaInlineValue(instance.CreateScriptInline)),
-								PlanModifiers:       []planmodifier.List{listplanmodifier.RequiresReplace()},
+								PlanModifiers:       []planmodifier.List{listplanmodifier.RequiresReplaceIf(requiresReplaceUnlessImportedList, requiresReplaceUnlessImportedDescription, requiresReplaceUnlessImportedDescription)},
 							},
 							"script": schema.StringAttribute{
 								MarkdownDescription: "Multiline shell script to be executed",
 								Optional:            true,
-								PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+								PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplaceIf(requiresReplaceUnlessImportedString, requiresReplaceUnlessImportedDescription, requiresReplaceUnlessImportedDescription)},
 							},
 						},
 					},
@@ -272,10 +507,78 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 						},
 					},
 				},
+				Blocks: map[string]schema.Block{
+					"instance": schema.ListNestedBlock{
+						MarkdownDescription: "AEM instance to be defined in the AEM Compose configuration. If any are set, they replace the instances defined in 'config'. Attributes not set are omitted, so AEM Compose defaults apply.",
+						NestedObject: schema.NestedBlockObject{
+							Attributes: map[string]schema.Attribute{
+								"id": schema.StringAttribute{
+									MarkdownDescription: "Unique identifier of the AEM instance, e.g. 'local_author'.",
+									Required:            true,
+								},
+								"http_url": schema.StringAttribute{
+									MarkdownDescription: "HTTP URL of the AEM instance, e.g. 'http://127.0.0.1:4502'.",
+									Required:            true,
+								},
+								"user": schema.StringAttribute{
+									MarkdownDescription: "User used to communicate with the AEM instance.",
+									Optional:            true,
+								},
+								"password": schema.StringAttribute{
+									MarkdownDescription: "Password used to communicate with the AEM instance. Write-only (requires Terraform 1.11 or later), so never stored in plan or state.",
+									Optional:            true,
+									Sensitive:           true,
+									WriteOnly:           true,
+								},
+								"run_modes": schema.ListAttribute{
+									MarkdownDescription: "Run modes of the AEM instance.",
+									ElementType:         types.StringType,
+									Optional:            true,
+								},
+								"jvm_opts": schema.ListAttribute{
+									MarkdownDescription: "JVM options of the AEM instance process.",
+									ElementType:         types.StringType,
+									Optional:            true,
+								},
+								"start_opts": schema.ListAttribute{
+									MarkdownDescription: "Options passed to the AEM quickstart.",
+									ElementType:         types.StringType,
+									Optional:            true,
+								},
+								"env_vars": schema.ListAttribute{
+									MarkdownDescription: "Environment variables of the AEM instance process in format 'NAME=value'.",
+									ElementType:         types.StringType,
+									Optional:            true,
+								},
+								"secret_vars": schema.ListAttribute{
+									MarkdownDescription: "Secret variables of the AEM instance process in format 'NAME=value'. Write-only (requires Terraform 1.11 or later), so never stored in plan or state.",
+									ElementType:         types.StringType,
+									Optional:            true,
+									Sensitive:           true,
+									WriteOnly:           true,
+								},
+								"sling_props": schema.ListAttribute{
+									MarkdownDescription: "Sling properties of the AEM instance in format 'name=value'.",
+									ElementType:         types.StringType,
+									Optional:            true,
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 
 		Attributes: map[string]schema.Attribute{
+			"host": schema.StringAttribute{
+				MarkdownDescription: "Name of the host defined in the provider inventory. Its connection settings are used if not overridden in the client block.",
+				Optional:            true,
+			},
+			"facts": schema.MapAttribute{
+				MarkdownDescription: "Facts about the host defined in the provider inventory.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
 			"files": schema.MapAttribute{
 				MarkdownDescription: "Files or directories to be copied into the machine.",
 				ElementType:         types.StringType,
@@ -283,47 +586,183 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 				Optional:            true,
 				Default:             mapdefault.StaticValue(types.MapValueMust(types.StringType, map[string]attr.Value{})),
 			},
+			"files_preserve": schema.ListAttribute{
+				MarkdownDescription: "Remote paths of files or directories copied from 'files' which are never deleted by the provider, even if no longer declared.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"files_cleanup": schema.BoolAttribute{
+				MarkdownDescription: "Toggle deletion of remote files and directories which were copied or written by the provider but are no longer declared in 'files' or 'file' blocks. Also deletes them when the resource is destroyed.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"files_managed": schema.ListAttribute{
+				MarkdownDescription: "Remote paths of files and directories copied or written by the provider, which are subject to cleanup. Preserved ones are excluded.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"files_checksums": schema.MapAttribute{
+				MarkdownDescription: "SHA-256 checksums of the contents of files or directories to be copied into the machine (keyed by local paths). Calculated once when planning; changing the contents of local files causes them to be copied again.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
 			"instances": schema.ListNestedAttribute{
 				MarkdownDescription: "Current state of the configured AEM instances.",
 				Computed:            true,
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"id": schema.StringAttribute{
-							MarkdownDescription: "Unique identifier of AEM instance defined in the configuration.",
-							Computed:            true,
-						},
-						"url": schema.StringAttribute{
-							MarkdownDescription: "The machine-internal HTTP URL address used for communication with the AEM instance.",
-							Computed:            true,
-						},
-						"aem_version": schema.StringAttribute{
-							MarkdownDescription: "Version of the AEM instance. Reflects service pack installations.",
-							Computed:            true,
-						},
-						"attributes": schema.ListAttribute{
-							MarkdownDescription: "A brief description of the state details for a specific AEM instance. Possible states include 'created', 'uncreated', 'running', 'unreachable', 'up-to-date', and 'out-of-date'.",
-							ElementType:         types.StringType,
-							Computed:            true,
-						},
-						"run_modes": schema.ListAttribute{
-							MarkdownDescription: "A list of run modes for a specific AEM instance.",
-							ElementType:         types.StringType,
-							Computed:            true,
-						},
-						"dir": schema.StringAttribute{
-							MarkdownDescription: "Remote path in which AEM instance is stored.",
-							Computed:            true,
-						},
-					},
-				},
+				NestedObject:        instanceStatusItemSchema(),
+			},
+			"instances_by_id": schema.MapNestedAttribute{
+				MarkdownDescription: "Current state of the configured AEM instances keyed by their identifiers.",
+				Computed:            true,
+				NestedObject:        instanceStatusItemSchema(),
 			},
 		},
 	}
 }
 
+// applyDefaults fills attributes not set in the resource configuration with the host, provider-level or built-in defaults.
+func (r *InstanceResource) applyDefaults(model *InstanceResourceModel, config InstanceResourceModel, host *client.Host) {
+	defaults := r.defaults
+
+	if config.Client != nil {
+		clientModel := r.clientWithDefaults(*config.Client, host)
+		model.Client = &clientModel
+	}
+	if host != nil && host.Unknown {
+		model.Facts = types.MapUnknown(types.StringType)
+	} else if host != nil {
+		model.Facts, _ = types.MapValueFrom(context.Background(), types.StringType, host.Facts)
+	} else {
+		model.Facts = types.MapValueMust(types.StringType, map[string]attr.Value{})
+	}
+	model.System.DataDir = stringWithDefault(config.System.DataDir, defaults.System.DataDir, types.StringValue(instance.DataDirDefault))
+	model.System.WorkDir = stringWithDefault(config.System.WorkDir, defaults.System.WorkDir, types.StringValue(instance.WorkDirDefault))
+	model.Compose.Version = stringWithDefault(config.Compose.Version, defaults.Compose.Version, types.StringValue(instance.ComposeVersionDefault))
+}
+
+func (r *InstanceResource) clientWithDefaults(config InstanceClientModel, host *client.Host) InstanceClientModel {
+	defaults := r.defaults.Client
+
+	hostType := types.StringNull()
+	hostSettings := types.MapNull(types.StringType)
+	hostCredentials := types.MapNull(types.StringType)
+	if host != nil && host.Unknown {
+		hostType = types.StringUnknown()
+		hostSettings = types.MapUnknown(types.StringType)
+		hostCredentials = types.MapUnknown(types.StringType)
+	} else if host != nil {
+		hostType = types.StringValue(host.TypeName)
+		hostSettings, _ = types.MapValueFrom(context.Background(), types.StringType, host.Settings)
+		hostCredentials, _ = types.MapValueFrom(context.Background(), types.StringType, host.Credentials)
+	}
+
+	return InstanceClientModel{
+		Type:          stringWithDefault(config.Type, hostType, defaults.Type),
+		Settings:      mapWithDefault(config.Settings, mapWithDefault(hostSettings, defaults.Settings)),
+		Credentials:   mapWithDefault(config.Credentials, mapWithDefault(hostCredentials, defaults.Credentials)),
+		ActionTimeout: stringWithDefault(config.ActionTimeout, defaults.ActionTimeout, types.StringValue(instance.ActionTimeoutDefault)),
+		StateTimeout:  stringWithDefault(config.StateTimeout, defaults.StateTimeout, types.StringValue(instance.StateTimeoutDefault)),
+	}
+}
+
+// clientModel returns the effective connection settings, also when the client block is omitted in the configuration.
+func (r *InstanceResource) clientModel(model InstanceResourceModel) InstanceClientModel {
+	if model.Client != nil {
+		return *model.Client
+	}
+	host, _ := r.host(model)
+	return r.clientWithDefaults(InstanceClientModel{}, host)
+}
+
+func (r *InstanceResource) host(model InstanceResourceModel) (*client.Host, error) {
+	if model.Host.IsNull() || model.Host.IsUnknown() || r.clientManager == nil {
+		return nil, nil
+	}
+	return r.clientManager.Host(model.Host.ValueString())
+}
+
+func stringWithDefault(value types.String, defaults ...types.String) types.String {
+	if !value.IsNull() {
+		return value
+	}
+	for _, defaultValue := range defaults {
+		if !defaultValue.IsNull() {
+			return defaultValue
+		}
+	}
+	return types.StringNull()
+}
+
+// mapWithDefault merges string maps so that the value entries take precedence over the default ones.
+func mapWithDefault(value types.Map, defaultValue types.Map) types.Map {
+	if value.IsUnknown() || defaultValue.IsUnknown() {
+		return types.MapUnknown(types.StringType)
+	}
+	if defaultValue.IsNull() {
+		return value
+	}
+	elements := map[string]attr.Value{}
+	maps.Copy(elements, defaultValue.Elements())
+	maps.Copy(elements, value.Elements())
+	return types.MapValueMust(types.StringType, elements)
+}
+
+// filesChecksums calculates checksums of the local files to be copied; unknown if some of them do not exist yet (e.g. are generated during the apply).
+func (r *InstanceResource) filesChecksums(ctx context.Context, files types.Map) (types.Map, error) {
+	if files.IsUnknown() {
+		return types.MapUnknown(types.StringType), nil
+	}
+	var filesMap map[string]string
+	files.ElementsAs(ctx, &filesMap, true)
+	checksums := map[string]string{}
+	for localPath := range filesMap {
+		if _, err := os.Stat(localPath); os.IsNotExist(err) {
+			return types.MapUnknown(types.StringType), nil
+		}
+		checksum, err := utils.PathChecksum(localPath)
+		if err != nil {
+			return types.MapNull(types.StringType), err
+		}
+		checksums[localPath] = checksum
+	}
+	result, _ := types.MapValueFrom(ctx, types.StringType, checksums)
+	return result, nil
+}
+
+// filesManaged lists remote paths of the declared files which are not preserved; unknown if some of them are not known yet.
+func (r *InstanceResource) filesManaged(ctx context.Context, model InstanceResourceModel) types.List {
+	if model.Files.IsUnknown() || model.FilesPreserve.IsUnknown() {
+		return types.ListUnknown(types.StringType)
+	}
+	var filesMap map[string]string
+	model.Files.ElementsAs(ctx, &filesMap, true)
+	var preserved []string
+	model.FilesPreserve.ElementsAs(ctx, &preserved, true)
+
+	managed := []string{}
+	for _, remotePath := range filesMap {
+		if !slices.Contains(preserved, remotePath) {
+			managed = append(managed, remotePath)
+		}
+	}
+	for _, file := range model.File {
+		if file.Path.IsUnknown() || file.Preserve.IsUnknown() {
+			return types.ListUnknown(types.StringType)
+		}
+		if !file.Preserve.ValueBool() {
+			managed = append(managed, file.Path.ValueString())
+		}
+	}
+	sort.Strings(managed)
+	result, _ := types.ListValueFrom(ctx, types.StringType, managed)
+	return result
+}
+
 func (r *InstanceResource) newModel() InstanceResourceModel {
 	model := InstanceResourceModel{}
 	model.Instances = types.ListValueMust(types.ObjectType{AttrTypes: InstanceStatusItemModel{}.attrTypes()}, []attr.Value{})
+	model.InstancesByID = types.MapValueMust(types.ObjectType{AttrTypes: InstanceStatusItemModel{}.attrTypes()}, map[string]attr.Value{})
 	return model
 }
 
@@ -331,24 +770,74 @@ func (r *InstanceResource) fillModelWithStatus(ctx context.Context, model *Insta
 	var allDiags diag.Diagnostics
 
 	instances := make([]InstanceStatusItemModel, len(status.Data.Instances))
+	instancesByID := map[string]InstanceStatusItemModel{}
 	for i, instance := range status.Data.Instances {
 		attributeList, diags := types.ListValueFrom(ctx, types.StringType, instance.Attributes)
 		allDiags.Append(diags...)
 		runModeList, diags := types.ListValueFrom(ctx, types.StringType, instance.RunModes)
 		allDiags.Append(diags...)
+		healthCheckList, diags := types.ListValueFrom(ctx, types.StringType, instance.HealthChecks)
+		allDiags.Append(diags...)
+
+		startTime := ""
+		if !instance.StartTime.IsZero() {
+			startTime = instance.StartTime.Format(time.RFC3339)
+		}
 
 		instances[i] = InstanceStatusItemModel{
-			ID:         types.StringValue(instance.ID),
-			URL:        types.StringValue(instance.URL),
-			AemVersion: types.StringValue(instance.AemVersion),
-			Dir:        types.StringValue(instance.Dir),
-			Attributes: attributeList,
-			RunModes:   runModeList,
+			ID:           types.StringValue(instance.ID),
+			URL:          types.StringValue(instance.URL),
+			AemVersion:   types.StringValue(instance.AemVersion),
+			Dir:          types.StringValue(instance.Dir),
+			Attributes:   attributeList,
+			RunModes:     runModeList,
+			HealthChecks: healthCheckList,
+			Running:      types.BoolValue(instance.Running()),
+			UpToDate:     types.BoolValue(instance.UpToDate()),
+			Healthy:      types.BoolValue(instance.Healthy()),
+			StartTime:    types.StringValue(startTime),
+			Local:        types.BoolValue(instance.Local()),
 		}
+		instancesByID[instance.ID] = instances[i]
 	}
 	instanceList, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: InstanceStatusItemModel{}.attrTypes()}, instances)
 	allDiags.Append(diags...)
 	model.Instances = instanceList
+	instanceMap, diags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: InstanceStatusItemModel{}.attrTypes()}, instancesByID)
+	allDiags.Append(diags...)
+	model.InstancesByID = instanceMap
 
 	return allDiags
 }
+
+// instanceStatusItemDescriptions documents the attributes of the instance status item, shared by the resource and data source schemas.
+var instanceStatusItemDescriptions = map[string]string{
+	"id":            "Unique identifier of AEM instance defined in the configuration.",
+	"url":           "The machine-internal HTTP URL address used for communication with the AEM instance.",
+	"aem_version":   "Version of the AEM instance. Reflects service pack installations.",
+	"attributes":    "A brief description of the state details for a specific AEM instance. Possible states include 'created', 'uncreated', 'running', 'unreachable', 'up-to-date', and 'out-of-date'.",
+	"run_modes":     "A list of run modes for a specific AEM instance.",
+	"dir":           "Remote path in which AEM instance is stored.",
+	"health_checks": "Health check issues reported for a specific AEM instance (e.g. unstable bundles, events or installer activity). Empty if all checks pass.",
+	"running":       "Whether the AEM instance is running.",
+	"up_to_date":    "Whether the AEM instance is up-to-date with the configuration.",
+	"healthy":       "Whether the AEM instance is running and no health check issues are reported.",
+	"start_time":    "Time (RFC 3339) at which the AEM instance process was started. Empty if not running or not local.",
+	"local":         "Whether the AEM instance is stored on the machine (managed by AEM Compose) or is only a remote one referred to by URL.",
+}
+
+func instanceStatusItemSchema() schema.NestedAttributeObject {
+	attributes := map[string]schema.Attribute{}
+	for name, attrType := range (InstanceStatusItemModel{}).attrTypes() {
+		description := instanceStatusItemDescriptions[name]
+		switch {
+		case attrType.Equal(types.BoolType):
+			attributes[name] = schema.BoolAttribute{MarkdownDescription: description, Computed: true}
+		case attrType.Equal(types.StringType):
+			attributes[name] = schema.StringAttribute{MarkdownDescription: description, Computed: true}
+		default:
+			attributes[name] = schema.ListAttribute{MarkdownDescription: description, ElementType: types.StringType, Computed: true}
+		}
+	}
+	return schema.NestedAttributeObject{Attributes: attributes}
+}
@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/spf13/cast"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type InstanceReadinessModel struct {
+	Instances  types.List   `tfsdk:"instances"`
+	Attributes types.List   `tfsdk:"attributes"`
+	Healthy    types.Bool   `tfsdk:"healthy"`
+	Bundles    types.List   `tfsdk:"bundles"`
+	Paths      types.Map    `tfsdk:"paths"`
+	User       types.String `tfsdk:"user"`
+	Password   types.String `tfsdk:"password"`
+	Timeout    types.String `tfsdk:"timeout"`
+	Interval   types.String `tfsdk:"interval"`
+}
+
+// awaitReadiness polls the status of AEM instances until all readiness criteria are met or the timeout is exceeded.
+func (ic *InstanceClient) awaitReadiness() error {
+	readiness := ic.data.Readiness
+	if readiness == nil {
+		return nil
+	}
+	timeout := cast.ToDuration(stringWithDefault(readiness.Timeout, types.StringValue(instance.ReadinessTimeoutDefault)).ValueString())
+	interval := cast.ToDuration(stringWithDefault(readiness.Interval, types.StringValue(instance.ReadinessIntervalDefault)).ValueString())
+
+	tflog.Info(ic.ctx, "Awaiting AEM instance(s) readiness")
+	curlConfigs := map[string]string{}
+	defer func() {
+		for _, curlConfig := range curlConfigs {
+			if curlConfig != "" {
+				_ = ic.cl.PathDelete(curlConfig)
+			}
+		}
+	}()
+	deadline := time.Now().Add(timeout)
+	for {
+		issues, err := ic.checkReadiness(*readiness, curlConfigs)
+		if err != nil {
+			issues = append(issues, err.Error())
+		}
+		if len(issues) == 0 {
+			tflog.Info(ic.ctx, "AEM instance(s) are ready")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("AEM instance(s) not ready within %s, last observed status:\n%s", timeout, strings.Join(issues, "\n"))
+		}
+		tflog.Info(ic.ctx, fmt.Sprintf("AEM instance(s) not ready yet:\n%s", strings.Join(issues, "\n")))
+		select {
+		case <-ic.ctx.Done():
+			return fmt.Errorf("awaiting AEM instance(s) readiness interrupted: %w", ic.ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// checkReadiness returns descriptions of the criteria not met; empty if all instances are ready.
+// Paths of curl config files with credentials saved so far are tracked by instance identifiers, so that each one is written only once.
+func (ic *InstanceClient) checkReadiness(readiness InstanceReadinessModel, curlConfigs map[string]string) ([]string, error) {
+	status, err := ic.ReadStatus()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read AEM instance status: %w", err)
+	}
+
+	var instanceIDs, attributes, bundles []string
+	readiness.Instances.ElementsAs(ic.ctx, &instanceIDs, true)
+	if readiness.Attributes.IsNull() {
+		attributes = instance.ReadinessAttributesDefault
+	} else {
+		readiness.Attributes.ElementsAs(ic.ctx, &attributes, true)
+	}
+	readiness.Bundles.ElementsAs(ic.ctx, &bundles, true)
+	var paths map[string]int64
+	readiness.Paths.ElementsAs(ic.ctx, &paths, true)
+
+	var issues []string
+	var items []InstanceStatusItem
+	if len(instanceIDs) == 0 {
+		for _, item := range status.Data.Instances {
+			if item.Local() {
+				items = append(items, item)
+			}
+		}
+	} else {
+		for _, id := range instanceIDs {
+			found := false
+			for _, item := range status.Data.Instances {
+				if item.ID == id {
+					items = append(items, item)
+					found = true
+				}
+			}
+			if !found {
+				issues = append(issues, fmt.Sprintf("instance '%s' not found", id))
+			}
+		}
+	}
+
+	for _, item := range items {
+		var missing []string
+		for _, attribute := range attributes {
+			if !item.hasAttribute(attribute) {
+				missing = append(missing, attribute)
+			}
+		}
+		if len(missing) > 0 {
+			issues = append(issues, fmt.Sprintf("instance '%s' is not '%s' (attributes: %s)", item.ID, strings.Join(missing, "', '"), strings.Join(item.Attributes, ", ")))
+			continue
+		}
+		if readiness.Healthy.ValueBool() && len(item.HealthChecks) > 0 {
+			issues = append(issues, fmt.Sprintf("instance '%s' is not healthy (health checks: %s)", item.ID, strings.Join(item.HealthChecks, ", ")))
+		}
+		if len(bundles) == 0 && len(paths) == 0 {
+			continue
+		}
+		curlAuth, err := ic.readinessCurlAuth(readiness, item.ID, curlConfigs)
+		if err != nil {
+			return nil, err
+		}
+		for _, bundle := range bundles {
+			state, err := ic.readBundleState(item.URL, curlAuth, bundle)
+			if err != nil {
+				issues = append(issues, fmt.Sprintf("instance '%s' bundle '%s' state cannot be read: %s", item.ID, bundle, err))
+			} else if state != "Active" && state != "Fragment" {
+				issues = append(issues, fmt.Sprintf("instance '%s' bundle '%s' is not active (state: '%s')", item.ID, bundle, state))
+			}
+		}
+		for path, expectedCode := range paths {
+			code, err := ic.readPathStatus(item.URL, curlAuth, path)
+			if err != nil {
+				issues = append(issues, fmt.Sprintf("instance '%s' path '%s' cannot be requested: %s", item.ID, path, err))
+			} else if code != expectedCode {
+				issues = append(issues, fmt.Sprintf("instance '%s' path '%s' responds with status %d (expected %d)", item.ID, path, code, expectedCode))
+			}
+		}
+	}
+	return issues, nil
+}
+
+func (ic *InstanceClient) readBundleState(instanceURL string, curlAuth string, symbolicName string) (string, error) {
+	out, err := ic.cl.RunShellPurely(fmt.Sprintf("curl -s %s '%s/system/console/bundles/%s.json'", curlAuth, instanceURL, symbolicName))
+	if err != nil {
+		return "", err
+	}
+	var bundles struct {
+		Data []struct {
+			State string `json:"state"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &bundles); err != nil {
+		return "", fmt.Errorf("unable to parse bundle details: %w", err)
+	}
+	if len(bundles.Data) == 0 {
+		return "", fmt.Errorf("bundle not found")
+	}
+	return bundles.Data[0].State, nil
+}
+
+func (ic *InstanceClient) readPathStatus(instanceURL string, curlAuth string, path string) (int64, error) {
+	out, err := ic.cl.RunShellPurely(fmt.Sprintf("curl -s -o /dev/null -w '%%{http_code}' %s '%s%s'", curlAuth, instanceURL, path))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// readinessCurlAuth points curl to the config file with credentials of the instance, so that they are not exposed in the process list or command history.
+// Requests are not authenticated if no password is set for the instance.
+func (ic *InstanceClient) readinessCurlAuth(readiness InstanceReadinessModel, instanceID string, curlConfigs map[string]string) (string, error) {
+	curlConfig, saved := curlConfigs[instanceID]
+	if !saved {
+		user, password := readinessCredentials(ic.ctx, readiness, ic.data, instanceID)
+		if password != "" {
+			curlConfig = fmt.Sprintf("%s/readiness-%s.curlrc", ic.cl.WorkDir, instanceID)
+			if err := ic.cl.FileWriteMode(curlConfig, readinessCurlConfig(user, password), "600"); err != nil {
+				return "", fmt.Errorf("cannot save AEM instance '%s' readiness credentials: %w", instanceID, err)
+			}
+		}
+		curlConfigs[instanceID] = curlConfig
+	}
+	if curlConfig == "" {
+		return "", nil
+	}
+	return fmt.Sprintf("-K '%s'", curlConfig), nil
+}
+
+// readinessCredentials determines the credentials of the instance; the password is taken from the readiness block or, if not set there, from the AEM Compose instance passwords.
+func readinessCredentials(ctx context.Context, readiness InstanceReadinessModel, model InstanceResourceModel, instanceID string) (string, string) {
+	user := readiness.User.ValueString()
+	password := readiness.Password.ValueString()
+	for _, instanceModel := range model.Compose.Instance {
+		if instanceModel.ID.ValueString() != instanceID {
+			continue
+		}
+		if user == "" {
+			user = instanceModel.User.ValueString()
+		}
+		if password == "" {
+			password = instanceModel.Password.ValueString()
+		}
+	}
+	if password == "" {
+		var passwords map[string]string
+		model.Compose.Passwords.ElementsAs(ctx, &passwords, true)
+		password = passwords[instanceID]
+	}
+	if user == "" {
+		user = instance.ReadinessUserDefault
+	}
+	return user, password
+}
+
+// readinessCurlConfig formats the credentials as a curl config file, escaping the characters special in quoted values.
+func readinessCurlConfig(user string, password string) string {
+	credentials := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\r", "\\r", "\t", "\\t").Replace(user + ":" + password)
+	return fmt.Sprintf("user = \"%s\"\n", credentials)
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestReadinessCredentials(t *testing.T) {
+	author := testComposeInstance("local_author", "http://127.0.0.1:4502")
+	author.User = types.StringValue("author-admin")
+	author.Password = types.StringValue("author-secret")
+	publish := testComposeInstance("local_publish", "http://127.0.0.1:4503")
+	model := testComposeModel("", "", author, publish)
+	model.Compose.Passwords = types.MapValueMust(types.StringType, map[string]attr.Value{"local_publish": types.StringValue("publish-secret")})
+
+	tests := []struct {
+		name         string
+		readiness    InstanceReadinessModel
+		instanceID   string
+		wantUser     string
+		wantPassword string
+	}{
+		{
+			name:         "readiness credentials take precedence",
+			readiness:    InstanceReadinessModel{User: types.StringValue("checker"), Password: types.StringValue("checker-secret")},
+			instanceID:   "local_author",
+			wantUser:     "checker",
+			wantPassword: "checker-secret",
+		},
+		{
+			name:         "instance block credentials",
+			readiness:    InstanceReadinessModel{User: types.StringNull(), Password: types.StringNull()},
+			instanceID:   "local_author",
+			wantUser:     "author-admin",
+			wantPassword: "author-secret",
+		},
+		{
+			name:         "instance password from passwords map",
+			readiness:    InstanceReadinessModel{User: types.StringNull(), Password: types.StringNull()},
+			instanceID:   "local_publish",
+			wantUser:     "admin",
+			wantPassword: "publish-secret",
+		},
+		{
+			name:         "no password set",
+			readiness:    InstanceReadinessModel{User: types.StringNull(), Password: types.StringNull()},
+			instanceID:   "local_preview",
+			wantUser:     "admin",
+			wantPassword: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, password := readinessCredentials(context.Background(), tt.readiness, model, tt.instanceID)
+			if user != tt.wantUser || password != tt.wantPassword {
+				t.Errorf("readinessCredentials() = (%q, %q), want (%q, %q)", user, password, tt.wantUser, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestReadinessCurlConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		want     string
+	}{
+		{name: "plain", user: "admin", password: "admin", want: "user = \"admin:admin\"\n"},
+		{name: "special characters escaped", user: "admin", password: "p\"a\\s\ns", want: "user = \"admin:p\\\"a\\\\s\\ns\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readinessCurlConfig(tt.user, tt.password); got != tt.want {
+				t.Errorf("readinessCurlConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/spf13/cast"
 	"github.com/wttech/terraform-provider-aem/internal/client"
@@ -16,13 +18,15 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &InstanceResource{}
 var _ resource.ResourceWithImportState = &InstanceResource{}
+var _ resource.ResourceWithModifyPlan = &InstanceResource{}
 
 func NewInstanceResource() resource.Resource {
-	return &InstanceResource{}
+	return &InstanceResource{defaults: AEMProviderModel{}.normalized()}
 }
 
 type InstanceResource struct {
 	clientManager *client.ClientManager
+	defaults      AEMProviderModel
 }
 
 func (r *InstanceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -35,28 +39,99 @@ func (r *InstanceResource) Configure(ctx context.Context, req resource.Configure
 		return
 	}
 
-	clientManager, ok := req.ProviderData.(*client.ClientManager)
+	providerData, ok := req.ProviderData.(*ProviderData)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *ClientManager, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.clientManager = clientManager
+	r.clientManager = providerData.ClientManager
+	r.defaults = providerData.Defaults
+}
+
+func (r *InstanceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	configModel := r.newModel()
+	resp.Diagnostics.Append(req.Config.Get(ctx, &configModel)...)
+	plannedModel := r.newModel()
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plannedModel)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	host, err := r.host(configModel)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("host"), "Unknown AEM instance host", fmt.Sprintf("%s", err))
+		return
+	}
+	r.applyDefaults(&plannedModel, configModel, host)
+	filesChecksums, err := r.filesChecksums(ctx, plannedModel.Files)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("files"), "Unable to calculate checksums of AEM instance files", fmt.Sprintf("%s", err))
+		return
+	}
+	plannedModel.FilesChecksums = filesChecksums
+	if err := r.fileChecksums(ctx, plannedModel.File); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("file"), "Invalid AEM instance file", fmt.Sprintf("%s", err))
+		return
+	}
+	plannedModel.FilesManaged = r.filesManaged(ctx, plannedModel)
+	if composeConfigKnown(plannedModel) {
+		if _, err := renderComposeConfig(ctx, plannedModel); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("compose"), "Invalid AEM Compose configuration", fmt.Sprintf("%s", err))
+			return
+		}
+	}
+	if serviceConfig := plannedModel.System.ServiceConfig; !serviceConfig.IsUnknown() && len(plannedModel.System.SecretEnv.Elements()) > 0 && !serviceConfigLoadsSecretEnv(serviceConfig.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("system").AtName("service_config"), "Invalid AEM system service definition", "Service definition needs to load secret environment variables using 'EnvironmentFile=-[[.SECRET_ENV_FILE]]'.")
+		return
+	}
+	if configModel.Host.IsUnknown() {
+		plannedModel.Facts = types.MapUnknown(types.StringType)
+	} else if host != nil && host.Unknown {
+		tflog.Info(ctx, fmt.Sprintf("AEM instance host '%s' is not known yet, so its connection will be determined when applying", configModel.Host.ValueString()))
+	} else if r.clientModel(plannedModel).Type.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("client").AtName("type"), "Missing AEM client type", "Client type needs to be set on the resource, host or provider level.")
+		return
+	}
+
+	if !req.State.Raw.IsNull() {
+		stateModel := r.newModel()
+		resp.Diagnostics.Append(req.State.Get(ctx, &stateModel)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !plannedModel.System.DataDir.Equal(stateModel.System.DataDir) {
+			resp.RequiresReplace = append(resp.RequiresReplace, path.Root("system").AtName("data_dir"))
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plannedModel)...)
 }
 
 func (r *InstanceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	r.createOrUpdate(ctx, &req.Plan, &resp.Diagnostics, &resp.State, true)
+	r.createOrUpdate(ctx, &req.Plan, &req.Config, nil, &resp.Diagnostics, &resp.State)
 }
 
 func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	r.createOrUpdate(ctx, &req.Plan, &resp.Diagnostics, &resp.State, false)
+	r.createOrUpdate(ctx, &req.Plan, &req.Config, &req.State, &resp.Diagnostics, &resp.State)
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, instanceImportedKey, []byte("false"))...)
+	}
 }
 
-func (r *InstanceResource) createOrUpdate(ctx context.Context, plan *tfsdk.Plan, diags *diag.Diagnostics, state *tfsdk.State, create bool) {
+// createOrUpdate sets up the AEM instance; the prior state is nil when the resource is being created.
+// Write-only secrets are read from the configuration as they are not present in the plan.
+func (r *InstanceResource) createOrUpdate(ctx context.Context, plan *tfsdk.Plan, config *tfsdk.Config, priorState *tfsdk.State, diags *diag.Diagnostics, state *tfsdk.State) {
+	create := priorState == nil
 	plannedModel := r.newModel()
 
 	// Read Terraform planned data into the model
@@ -64,10 +139,22 @@ func (r *InstanceResource) createOrUpdate(ctx context.Context, plan *tfsdk.Plan,
 	if diags.HasError() {
 		return
 	}
+	configModel := r.newModel()
+	diags.Append(config.Get(ctx, &configModel)...)
+	if diags.HasError() {
+		return
+	}
+	priorModel := r.newModel()
+	if !create {
+		diags.Append(priorState.Get(ctx, &priorModel)...)
+		if diags.HasError() {
+			return
+		}
+	}
 
 	tflog.Info(ctx, "Started setting up AEM instance resource")
 
-	ic, err := r.client(ctx, plannedModel, cast.ToDuration(plannedModel.Client.ActionTimeout.ValueString()))
+	ic, err := r.client(ctx, withSecrets(plannedModel, configModel), cast.ToDuration(r.clientModel(plannedModel).ActionTimeout.ValueString()))
 	if err != nil {
 		diags.AddError("Unable to connect to AEM instance", fmt.Sprintf("%s", err))
 		return
@@ -85,10 +172,35 @@ func (r *InstanceResource) createOrUpdate(ctx context.Context, plan *tfsdk.Plan,
 			return
 		}
 	}
+	plannedModel.FilesManaged = r.filesManaged(ctx, plannedModel)
+	ic.data.FilesManaged = plannedModel.FilesManaged
+	if !create {
+		// before writing, so that fresh files under removed directories are not deleted
+		if err := ic.cleanupFiles(priorModel.FilesManaged); err != nil {
+			diags.AddError("Unable to clean up AEM instance files", fmt.Sprintf("%s", err))
+			return
+		}
+	}
 	if err := ic.copyFiles(); err != nil {
 		diags.AddError("Unable to copy AEM instance files", fmt.Sprintf("%s", err))
 		return
 	}
+	if plannedModel.FilesChecksums.IsUnknown() {
+		filesChecksums, err := r.filesChecksums(ctx, plannedModel.Files)
+		if err != nil {
+			diags.AddError("Unable to calculate checksums of AEM instance files", fmt.Sprintf("%s", err))
+			return
+		}
+		plannedModel.FilesChecksums = filesChecksums
+	}
+	if err := ic.writeFiles(); err != nil {
+		diags.AddError("Unable to write AEM instance files", fmt.Sprintf("%s", err))
+		return
+	}
+	if err := r.fileChecksums(ctx, plannedModel.File); err != nil {
+		diags.AddError("Unable to calculate checksums of AEM instance files", fmt.Sprintf("%s", err))
+		return
+	}
 	if err := ic.prepareWorkDir(); err != nil {
 		diags.AddError("Unable to prepare AEM work directory", fmt.Sprintf("%s", err))
 		return
@@ -97,6 +209,10 @@ func (r *InstanceResource) createOrUpdate(ctx context.Context, plan *tfsdk.Plan,
 		diags.AddError("Unable to prepare AEM data directory", fmt.Sprintf("%s", err))
 		return
 	}
+	if err := ic.fetchArtifacts(); err != nil {
+		diags.AddError("Unable to fetch AEM instance artifacts", fmt.Sprintf("%s", err))
+		return
+	}
 	if err := ic.installComposeCLI(); err != nil {
 		diags.AddError("Unable to install AEM Compose CLI", fmt.Sprintf("%s", err))
 		return
@@ -105,16 +221,25 @@ func (r *InstanceResource) createOrUpdate(ctx context.Context, plan *tfsdk.Plan,
 		diags.AddError("Unable to write AEM configuration file", fmt.Sprintf("%s", err))
 		return
 	}
+	systemChanged, err := ic.configureSystem()
+	if err != nil {
+		diags.AddError("Unable to configure AEM system service", fmt.Sprintf("%s", err))
+		return
+	}
 	if create {
 		if err := ic.create(); err != nil {
 			diags.AddError("Unable to create AEM instance", fmt.Sprintf("%s", err))
 			return
 		}
 	}
-	if err := ic.launch(); err != nil {
+	if err := ic.launch(systemChanged && !create); err != nil {
 		diags.AddError("Unable to launch AEM instance", fmt.Sprintf("%s", err))
 		return
 	}
+	if err := ic.awaitReadiness(); err != nil {
+		diags.AddError("AEM instance is not ready", fmt.Sprintf("%s", err))
+		return
+	}
 
 	tflog.Info(ctx, "Finished setting up AEM instance resource")
 
@@ -139,9 +264,15 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
-	ic, err := r.client(ctx, model, cast.ToDuration(model.Client.StateTimeout.ValueString()))
+	if host, _ := r.host(model); host != nil && host.Unknown && req.ClientCapabilities.DeferralAllowed {
+		resp.Deferred = &resource.Deferred{Reason: resource.DeferredReasonProviderConfigUnknown}
+		return
+	}
+
+	ic, err := r.client(ctx, model, cast.ToDuration(r.clientModel(model).StateTimeout.ValueString()))
 	if err != nil {
 		tflog.Info(ctx, "Cannot read AEM instance state as it is not possible to connect	 at the moment. Possible reasons: machine IP change is in progress, machine is not yet created or booting up, etc.")
+		resp.Diagnostics.AddWarning("AEM instance machine unreachable", fmt.Sprintf("State of AEM instance is not refreshed as connecting to the machine failed: %s", err))
 	} else {
 		defer func(ic *InstanceClient) {
 			err := ic.Close()
@@ -150,13 +281,43 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 			}
 		}(ic)
 
+		exists, err := ic.deploymentExists()
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to read AEM instance deployment", fmt.Sprintf("%s", err))
+			return
+		}
+		if !exists {
+			resp.Diagnostics.AddWarning("AEM instance deployment missing", fmt.Sprintf("AEM Compose configuration file '%s' not found on the machine. Resource is removed from the state so that it will be created again.", ic.configFilePath()))
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
 		status, err := ic.ReadStatus()
-		if err != nil { //
+		if err != nil {
 			resp.Diagnostics.AddError("Unable to read AEM instance status", fmt.Sprintf("%s", err))
 			return
 		}
 
 		resp.Diagnostics.Append(r.fillModelWithStatus(ctx, &model, status)...)
+
+		if model.Compose.Download.ValueBool() {
+			composeVersion, err := ic.ReadComposeVersion()
+			if err != nil {
+				resp.Diagnostics.AddError("Unable to read AEM Compose CLI version", fmt.Sprintf("%s", err))
+				return
+			}
+			if composeVersion != "" {
+				model.Compose.Version = types.StringValue(composeVersion)
+			}
+		}
+
+		if err := ic.readDeployment(); err != nil {
+			resp.Diagnostics.AddError("Unable to read AEM instance deployment", fmt.Sprintf("%s", err))
+			return
+		}
+		model.Compose.Config = ic.data.Compose.Config
+		model.System.ServiceConfig = ic.data.System.ServiceConfig
+		model.System.Env = ic.data.System.Env
 	}
 
 	// Save updated data into Terraform state
@@ -174,7 +335,7 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 
 	tflog.Info(ctx, "Started deleting AEM instance resource")
 
-	ic, err := r.client(ctx, model, cast.ToDuration(model.Client.StateTimeout.ValueString()))
+	ic, err := r.client(ctx, model, cast.ToDuration(r.clientModel(model).StateTimeout.ValueString()))
 	if err != nil {
 		resp.Diagnostics.AddError("Unable to connect to AEM instance", fmt.Sprintf("%s", err))
 		return
@@ -196,18 +357,25 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
-	tflog.Info(ctx, "Finished deleting AEM instance resource")
-}
+	if err := ic.deleteManagedFiles(); err != nil {
+		resp.Diagnostics.AddError("Unable to delete AEM instance files", fmt.Sprintf("%s", err))
+		return
+	}
 
-func (r *InstanceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resp.Diagnostics.AddError("Import state error", "Not supported for AEM instance resource")
+	tflog.Info(ctx, "Finished deleting AEM instance resource")
 }
 
 func (r *InstanceResource) client(ctx context.Context, model InstanceResourceModel, timeout time.Duration) (*InstanceClient, error) {
-	typeName := model.Client.Type.ValueString()
+	if host, err := r.host(model); err != nil {
+		return nil, err
+	} else if host != nil && host.Unknown {
+		return nil, fmt.Errorf("connection settings of host '%s' are not known yet", model.Host.ValueString())
+	}
+	clientModel := r.clientModel(model)
+	typeName := clientModel.Type.ValueString()
 	tflog.Info(ctx, fmt.Sprintf("Connecting to AEM instance machine using %s", typeName))
 
-	cl, err := r.clientManager.Make(typeName, r.clientSettings(ctx, model))
+	cl, err := r.clientManager.Make(typeName, r.clientSettings(ctx, clientModel))
 	if err != nil {
 		return nil, err
 	}
@@ -228,11 +396,11 @@ func (r *InstanceResource) client(ctx context.Context, model InstanceResourceMod
 	return &InstanceClient{cl, ctx, model}, nil
 }
 
-func (r *InstanceResource) clientSettings(ctx context.Context, model InstanceResourceModel) map[string]string {
+func (r *InstanceResource) clientSettings(ctx context.Context, model InstanceClientModel) map[string]string {
 	var settings map[string]string
-	model.Client.Settings.ElementsAs(ctx, &settings, true)
+	model.Settings.ElementsAs(ctx, &settings, true)
 	var credentials map[string]string
-	model.Client.Credentials.ElementsAs(ctx, &credentials, true)
+	model.Credentials.ElementsAs(ctx, &credentials, true)
 
 	combined := map[string]string{}
 	maps.Copy(combined, credentials)
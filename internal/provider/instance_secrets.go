@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+// composeSecretRedacted replaces secret values in the AEM Compose configuration read from the machine before it is stored in the state.
+const composeSecretRedacted = "(sensitive)"
+
+// withSecrets completes the planned model with the write-only secrets which are available in the configuration only.
+func withSecrets(model InstanceResourceModel, config InstanceResourceModel) InstanceResourceModel {
+	model.Compose.Passwords = config.Compose.Passwords
+	model.Compose.SecretVars = config.Compose.SecretVars
+	instances := slices.Clone(model.Compose.Instance)
+	for i := range instances {
+		if i < len(config.Compose.Instance) {
+			instances[i].Password = config.Compose.Instance[i].Password
+			instances[i].SecretVars = config.Compose.Instance[i].SecretVars
+		}
+	}
+	model.Compose.Instance = instances
+	if model.Readiness != nil && config.Readiness != nil {
+		readiness := *model.Readiness
+		readiness.Password = config.Readiness.Password
+		model.Readiness = &readiness
+	}
+	return model
+}
+
+// injectComposeSecrets sets the instance passwords and secret variables in the AEM Compose configuration.
+// Secrets are kept out of 'compose.config', so they are only present in the file written on the machine.
+func injectComposeSecrets(ctx context.Context, config string, model InstanceResourceModel) (string, error) {
+	var passwords, secretVars map[string]string
+	model.Compose.Passwords.ElementsAs(ctx, &passwords, true)
+	model.Compose.SecretVars.ElementsAs(ctx, &secretVars, true)
+	if len(passwords) == 0 && len(secretVars) == 0 {
+		return config, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		return "", fmt.Errorf("cannot parse AEM Compose configuration: %w", err)
+	}
+	instances := composeInstancesNode(&doc)
+	if instances == nil {
+		return "", fmt.Errorf("AEM Compose configuration does not define any instances to set secrets for")
+	}
+	for id, password := range passwords {
+		instanceNode := configMappingValue(instances, id)
+		if instanceNode == nil || instanceNode.Kind != yaml.MappingNode {
+			return "", fmt.Errorf("cannot set password of AEM instance '%s' as it is not defined in the configuration", id)
+		}
+		setConfigNode(instanceNode, []string{"password"}, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: password})
+	}
+	names := make([]string, 0, len(secretVars))
+	for name := range secretVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i := 1; i < len(instances.Content); i += 2 {
+		instanceNode := instances.Content[i]
+		if instanceNode.Kind != yaml.MappingNode || len(names) == 0 {
+			continue
+		}
+		varsNode := configMappingValue(instanceNode, "secret_vars")
+		if varsNode == nil || varsNode.Kind != yaml.SequenceNode {
+			varsNode = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			setConfigNode(instanceNode, []string{"secret_vars"}, varsNode)
+			varsNode = configMappingValue(instanceNode, "secret_vars")
+		}
+		for _, name := range names {
+			entry := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprintf("%s=%s", name, secretVars[name])}
+			replaced := false
+			for j, existing := range varsNode.Content {
+				if strings.HasPrefix(existing.Value, name+"=") {
+					varsNode.Content[j] = entry
+					replaced = true
+				}
+			}
+			if !replaced {
+				varsNode.Content = append(varsNode.Content, entry)
+			}
+		}
+	}
+	return encodeConfigDocument(&doc)
+}
+
+// redactComposeSecrets masks instance passwords and secret variable values, so that the configuration read from the machine could be stored in the state.
+func redactComposeSecrets(config string) string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		return config
+	}
+	instances := composeInstancesNode(&doc)
+	if instances == nil {
+		return config
+	}
+	for i := 1; i < len(instances.Content); i += 2 {
+		instanceNode := instances.Content[i]
+		if instanceNode.Kind != yaml.MappingNode {
+			continue
+		}
+		if password := configMappingValue(instanceNode, "password"); password != nil && password.Kind == yaml.ScalarNode {
+			password.Value = composeSecretRedacted
+			password.Tag = "!!str"
+		}
+		if secretVars := configMappingValue(instanceNode, "secret_vars"); secretVars != nil && secretVars.Kind == yaml.SequenceNode {
+			for _, entry := range secretVars.Content {
+				if name, _, found := strings.Cut(entry.Value, "="); found {
+					entry.Value = fmt.Sprintf("%s=%s", name, composeSecretRedacted)
+				}
+			}
+		}
+	}
+	redacted, err := encodeConfigDocument(&doc)
+	if err != nil {
+		return config
+	}
+	return redacted
+}
+
+// stripComposeSecrets removes instance passwords and secret variables, so that configurations could be compared without knowing the write-only secrets.
+func stripComposeSecrets(config string) string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(config), &doc); err != nil {
+		return config
+	}
+	instances := composeInstancesNode(&doc)
+	if instances == nil {
+		return config
+	}
+	for i := 1; i < len(instances.Content); i += 2 {
+		instanceNode := instances.Content[i]
+		if instanceNode.Kind != yaml.MappingNode {
+			continue
+		}
+		var content []*yaml.Node
+		for j := 0; j+1 < len(instanceNode.Content); j += 2 {
+			if key := instanceNode.Content[j].Value; key != "password" && key != "secret_vars" {
+				content = append(content, instanceNode.Content[j], instanceNode.Content[j+1])
+			}
+		}
+		instanceNode.Content = content
+	}
+	stripped, err := encodeConfigDocument(&doc)
+	if err != nil {
+		return config
+	}
+	return stripped
+}
+
+func composeInstancesNode(doc *yaml.Node) *yaml.Node {
+	root := configDocumentRoot(doc)
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	instanceNode := configMappingValue(root, "instance")
+	if instanceNode == nil || instanceNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	configNode := configMappingValue(instanceNode, "config")
+	if configNode == nil || configNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	return configNode
+}
+
+func encodeConfigDocument(doc *yaml.Node) (string, error) {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return "", fmt.Errorf("cannot encode AEM Compose configuration: %w", err)
+	}
+	_ = encoder.Close()
+	return out.String(), nil
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func testSecretsModel(passwords map[string]string, secretVars map[string]string) InstanceResourceModel {
+	toMap := func(values map[string]string) types.Map {
+		if values == nil {
+			return types.MapNull(types.StringType)
+		}
+		elements := map[string]attr.Value{}
+		for name, value := range values {
+			elements[name] = types.StringValue(value)
+		}
+		return types.MapValueMust(types.StringType, elements)
+	}
+	model := InstanceResourceModel{}
+	model.Compose.Passwords = toMap(passwords)
+	model.Compose.SecretVars = toMap(secretVars)
+	return model
+}
+
+const testSecretsConfig = "instance:\n  config:\n    local_author:\n      http_url: http://127.0.0.1:4502\n      secret_vars:\n        - TOKEN=old\n    local_publish:\n      http_url: http://127.0.0.1:4503\n"
+
+func TestInjectComposeSecrets(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		passwords  map[string]string
+		secretVars map[string]string
+		want       string
+		wantError  string
+	}{
+		{
+			name:   "no secrets",
+			config: "log:\n  level: info\n",
+			want:   "log:\n  level: info\n",
+		},
+		{
+			name:       "passwords set and secret variables added or replaced",
+			config:     testSecretsConfig,
+			passwords:  map[string]string{"local_author": "s3cr3t"},
+			secretVars: map[string]string{"TOKEN": "new", "API_KEY": "k'ey"},
+			want:       "instance:\n  config:\n    local_author:\n      http_url: http://127.0.0.1:4502\n      secret_vars:\n        - TOKEN=new\n        - API_KEY=k'ey\n      password: s3cr3t\n    local_publish:\n      http_url: http://127.0.0.1:4503\n      secret_vars:\n        - API_KEY=k'ey\n        - TOKEN=new\n",
+		},
+		{
+			name:      "password for undefined instance",
+			config:    testSecretsConfig,
+			passwords: map[string]string{"local_preview": "s3cr3t"},
+			wantError: "'local_preview' as it is not defined",
+		},
+		{
+			name:       "no instances defined",
+			config:     "log:\n  level: info\n",
+			secretVars: map[string]string{"TOKEN": "new"},
+			wantError:  "does not define any instances",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := injectComposeSecrets(context.Background(), tt.config, testSecretsModel(tt.passwords, tt.secretVars))
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing '%s', got: %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("injectComposeSecrets() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactComposeSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "passwords and secret variable values masked",
+			config: "instance:\n  config:\n    local_author:\n      password: s3cr3t\n      secret_vars:\n        - TOKEN=abc=def\n        - MALFORMED\n",
+			want:   "instance:\n  config:\n    local_author:\n      password: (sensitive)\n      secret_vars:\n        - TOKEN=(sensitive)\n        - MALFORMED\n",
+		},
+		{
+			name:   "no instances",
+			config: "log:\n  level: info\n",
+			want:   "log:\n  level: info\n",
+		},
+		{
+			name:   "invalid YAML returned as-is",
+			config: "instance: [",
+			want:   "instance: [",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactComposeSecrets(tt.config); got != tt.want {
+				t.Errorf("redactComposeSecrets() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripComposeSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "passwords and secret variables removed",
+			config: "instance:\n  config:\n    local_author:\n      http_url: http://127.0.0.1:4502\n      password: s3cr3t\n      secret_vars:\n        - TOKEN=abc\n",
+			want:   "instance:\n  config:\n    local_author:\n      http_url: http://127.0.0.1:4502\n",
+		},
+		{
+			name:   "no instances",
+			config: "log:\n  level: info\n",
+			want:   "log:\n  level: info\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripComposeSecrets(tt.config); got != tt.want {
+				t.Errorf("stripComposeSecrets() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("injected secrets stripped back", func(t *testing.T) {
+		injected, err := injectComposeSecrets(context.Background(), testSecretsConfig, testSecretsModel(map[string]string{"local_author": "s3cr3t"}, map[string]string{"TOKEN": "new"}))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		equal, err := yamlSemanticEqual(stripComposeSecrets(injected), stripComposeSecrets(testSecretsConfig))
+		if err != nil || !equal {
+			t.Errorf("configurations differ after stripping secrets:\n%s", stripComposeSecrets(injected))
+		}
+	})
+}
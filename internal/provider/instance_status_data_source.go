@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/spf13/cast"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &InstanceStatusDataSource{}
+var _ datasource.DataSourceWithConfigure = &InstanceStatusDataSource{}
+
+func NewInstanceStatusDataSource() datasource.DataSource {
+	return &InstanceStatusDataSource{instanceResource: &InstanceResource{defaults: AEMProviderModel{}.normalized()}}
+}
+
+// InstanceStatusDataSource reads the status of AEM instances managed by AEM Compose on a machine not necessarily managed by the 'aem_instance' resource.
+// Connecting and reading the status is delegated to the resource to make both behave the same way.
+type InstanceStatusDataSource struct {
+	instanceResource *InstanceResource
+}
+
+type InstanceStatusDataSourceModel struct {
+	Client         *InstanceStatusClientModel `tfsdk:"client"`
+	Host           types.String               `tfsdk:"host"`
+	DataDir        types.String               `tfsdk:"data_dir"`
+	WorkDir        types.String               `tfsdk:"work_dir"`
+	ComposeVersion types.String               `tfsdk:"compose_version"`
+	Instances      types.List                 `tfsdk:"instances"`
+	InstancesByID  types.Map                  `tfsdk:"instances_by_id"`
+}
+
+type InstanceStatusClientModel struct {
+	Type         types.String `tfsdk:"type"`
+	Settings     types.Map    `tfsdk:"settings"`
+	Credentials  types.Map    `tfsdk:"credentials"`
+	StateTimeout types.String `tfsdk:"state_timeout"`
+}
+
+func (d *InstanceStatusDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instance_status"
+}
+
+func (d *InstanceStatusDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Reads the status of AEM instances managed by AEM Compose on a machine. Useful for machines provisioned outside of the current Terraform workspace.",
+		Attributes: map[string]schema.Attribute{
+			"host": schema.StringAttribute{
+				MarkdownDescription: "Name of the machine defined in the provider inventory. Its connection settings are used as defaults for the client block.",
+				Optional:            true,
+			},
+			"data_dir": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Remote root path in which AEM Compose files and unpacked AEM instances are stored. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.DataDirDefault),
+				Optional:            true,
+				Computed:            true,
+			},
+			"work_dir": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Remote root path where provider-related files are stored. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.WorkDirDefault),
+				Optional:            true,
+				Computed:            true,
+			},
+			"compose_version": schema.StringAttribute{
+				MarkdownDescription: "Version of AEM Compose tool expected on the remote machine. If set, reading fails when the provider installed a different version there. Reports the installed version.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"instances": schema.ListNestedAttribute{
+				MarkdownDescription: "Current state of the AEM instances found on the machine.",
+				Computed:            true,
+				NestedObject:        instanceStatusItemDataSourceSchema(),
+			},
+			"instances_by_id": schema.MapNestedAttribute{
+				MarkdownDescription: "Current state of the AEM instances found on the machine keyed by their identifiers.",
+				Computed:            true,
+				NestedObject:        instanceStatusItemDataSourceSchema(),
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"client": schema.SingleNestedBlock{
+				MarkdownDescription: "Connection settings used to access the machine on which the AEM instances are running. May be omitted if the host or provider-level defaults are used.",
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						MarkdownDescription: "Type of connection to use to connect to the machine. Inherited from the provider if not set.",
+						Optional:            true,
+					},
+					"settings": schema.MapAttribute{
+						MarkdownDescription: "Settings for the connection type. Merged with the ones defined on the provider level.",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"credentials": schema.MapAttribute{
+						MarkdownDescription: "Credentials for the connection type. Merged with the ones defined on the provider level.",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"state_timeout": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Used when connecting to the machine. Inherited from the provider if not set, otherwise defaults to '%s'.", instance.StateTimeoutDefault),
+						Optional:            true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *InstanceStatusDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.instanceResource.clientManager = providerData.ClientManager
+	d.instanceResource.defaults = providerData.Defaults
+}
+
+func (d *InstanceStatusDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data InstanceStatusDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r := d.instanceResource
+	model := d.instanceModel(data)
+	host, err := r.host(model)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("host"), "Unknown AEM instance host", fmt.Sprintf("%s", err))
+		return
+	}
+	if host != nil && host.Unknown {
+		if req.ClientCapabilities.DeferralAllowed {
+			resp.Deferred = &datasource.Deferred{Reason: datasource.DeferredReasonProviderConfigUnknown}
+			return
+		}
+		resp.Diagnostics.AddAttributeError(path.Root("host"), "AEM instance host not known yet", fmt.Sprintf("Connection settings of host '%s' depend on values known only after apply. Apply the resources they depend on first.", data.Host.ValueString()))
+		return
+	}
+	r.applyDefaults(&model, model, host)
+	clientModel := r.clientModel(model)
+	if clientModel.Type.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("client").AtName("type"), "Missing AEM client type", "Client type needs to be set on the data source, host or provider level.")
+		return
+	}
+
+	tflog.Info(ctx, "Started reading AEM instance status")
+
+	ic, err := r.client(ctx, model, cast.ToDuration(clientModel.StateTimeout.ValueString()))
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to connect to AEM instance", fmt.Sprintf("%s", err))
+		return
+	}
+	defer func(ic *InstanceClient) {
+		err := ic.Close()
+		if err != nil {
+			resp.Diagnostics.AddWarning("Unable to disconnect from AEM instance", fmt.Sprintf("%s", err))
+		}
+	}(ic)
+
+	composeVersion, err := ic.ReadComposeVersion()
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to read AEM Compose CLI version", fmt.Sprintf("%s", err))
+		return
+	}
+	if composeVersion != "" && !data.ComposeVersion.IsNull() && composeVersion != data.ComposeVersion.ValueString() {
+		resp.Diagnostics.AddAttributeError(path.Root("compose_version"), "Unexpected AEM Compose CLI version", fmt.Sprintf("AEM Compose CLI version '%s' is installed on the machine, expected '%s'.", composeVersion, data.ComposeVersion.ValueString()))
+		return
+	}
+	if composeVersion != "" {
+		model.Compose.Version = types.StringValue(composeVersion)
+	}
+
+	status, err := ic.ReadStatus()
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to read AEM instance status", fmt.Sprintf("%s", err))
+		return
+	}
+	resp.Diagnostics.Append(r.fillModelWithStatus(ctx, &model, status)...)
+
+	tflog.Info(ctx, "Finished reading AEM instance status")
+
+	data.DataDir = model.System.DataDir
+	data.WorkDir = model.System.WorkDir
+	data.ComposeVersion = model.Compose.Version
+	data.Instances = model.Instances
+	data.InstancesByID = model.InstancesByID
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// instanceModel maps the data source configuration to the resource model, so that the same defaults and connection logic apply.
+func (d *InstanceStatusDataSource) instanceModel(data InstanceStatusDataSourceModel) InstanceResourceModel {
+	model := d.instanceResource.newModel()
+	if data.Client != nil {
+		model.Client = &InstanceClientModel{
+			Type:          data.Client.Type,
+			Settings:      data.Client.Settings,
+			Credentials:   data.Client.Credentials,
+			ActionTimeout: types.StringNull(),
+			StateTimeout:  data.Client.StateTimeout,
+		}
+	}
+	model.Host = data.Host
+	model.System.DataDir = data.DataDir
+	model.System.WorkDir = data.WorkDir
+	model.Compose.Version = data.ComposeVersion
+	return model
+}
+
+// instanceStatusItemDataSourceSchema mirrors the resource one, as the schema types of resources and data sources are distinct.
+func instanceStatusItemDataSourceSchema() schema.NestedAttributeObject {
+	attributes := map[string]schema.Attribute{}
+	for name, attrType := range (InstanceStatusItemModel{}).attrTypes() {
+		description := instanceStatusItemDescriptions[name]
+		switch {
+		case attrType.Equal(types.BoolType):
+			attributes[name] = schema.BoolAttribute{MarkdownDescription: description, Computed: true}
+		case attrType.Equal(types.StringType):
+			attributes[name] = schema.StringAttribute{MarkdownDescription: description, Computed: true}
+		default:
+			attributes[name] = schema.ListAttribute{MarkdownDescription: description, ElementType: types.StringType, Computed: true}
+		}
+	}
+	return schema.NestedAttributeObject{Attributes: attributes}
+}
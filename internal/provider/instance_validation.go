@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ resource.ResourceWithValidateConfig = &InstanceResource{}
+
+type configKind int
+
+const (
+	configAny configKind = iota
+	configMapping
+	configString
+	configBool
+	configInt
+	configDuration
+	configURL
+	configEnum
+	configList
+)
+
+// configRule describes the expected shape of an AEM Compose configuration value.
+// Mappings with 'values' set have arbitrary keys (e.g. instance identifiers).
+type configRule struct {
+	kind   configKind
+	fields map[string]configRule
+	values *configRule
+	enum   []string
+}
+
+var composeInstanceRule = configRule{kind: configMapping, fields: map[string]configRule{
+	"active":      {kind: configBool},
+	"http_url":    {kind: configURL},
+	"user":        {kind: configString},
+	"password":    {kind: configString},
+	"version":     {kind: configString},
+	"run_modes":   {kind: configList},
+	"jvm_opts":    {kind: configList},
+	"start_opts":  {kind: configList},
+	"env_vars":    {kind: configList},
+	"secret_vars": {kind: configList},
+	"sling_props": {kind: configList},
+}}
+
+// composeConfigRule covers the AEM Compose configuration keys which are commonly customized; other sections are not checked.
+var composeConfigRule = configRule{kind: configMapping, fields: map[string]configRule{
+	"instance": {kind: configMapping, fields: map[string]configRule{
+		"config":          {kind: configMapping, values: &composeInstanceRule},
+		"processing_mode": {kind: configEnum, enum: []string{"auto", "parallel", "serial"}},
+		"http": {kind: configMapping, fields: map[string]configRule{
+			"timeout":      {kind: configDuration},
+			"debug":        {kind: configBool},
+			"disable_warn": {kind: configBool},
+		}},
+		"check": {kind: configMapping, fields: map[string]configRule{
+			"warmup":         {kind: configDuration},
+			"interval":       {kind: configDuration},
+			"done_threshold": {kind: configInt},
+			"await_strict":   {kind: configBool},
+			"await_started": {kind: configMapping, fields: map[string]configRule{
+				"timeout": {kind: configDuration},
+			}},
+			"await_stopped": {kind: configMapping, fields: map[string]configRule{
+				"timeout": {kind: configDuration},
+			}},
+			"reachable": {kind: configMapping, fields: map[string]configRule{
+				"skip":    {kind: configBool},
+				"timeout": {kind: configDuration},
+			}},
+			"path_ready": {kind: configMapping, fields: map[string]configRule{
+				"timeout": {kind: configDuration},
+			}},
+			"bundle_stable":    {kind: configAny},
+			"event_stable":     {kind: configAny},
+			"component_stable": {kind: configAny},
+			"installer":        {kind: configAny},
+			"login_page":       {kind: configAny},
+		}},
+		"local": {kind: configAny},
+		"status": {kind: configMapping, fields: map[string]configRule{
+			"timeout": {kind: configDuration},
+		}},
+		"repo":    {kind: configAny},
+		"package": {kind: configAny},
+		"ssl": {kind: configMapping, fields: map[string]configRule{
+			"setup_timeout": {kind: configDuration},
+		}},
+		"osgi":        {kind: configAny},
+		"crypto":      {kind: configAny},
+		"replication": {kind: configAny},
+		"workflow":    {kind: configAny},
+	}},
+	"java": {kind: configAny},
+	"base": {kind: configAny},
+	"log": {kind: configMapping, fields: map[string]configRule{
+		"level":            {kind: configEnum, enum: []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}},
+		"timestamp_format": {kind: configString},
+		"full_timestamp":   {kind: configBool},
+	}},
+	"input":  {kind: configAny},
+	"output": {kind: configAny},
+}}
+
+var composeInstanceIDRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// configIssue is a problem found in the AEM Compose configuration; unknown keys are only warnings as AEM Compose versions differ.
+type configIssue struct {
+	key     string
+	message string
+	warning bool
+}
+
+func (r *InstanceResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	model := r.newModel()
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if model.Compose.Config.IsUnknown() || model.Compose.Overrides.IsUnknown() {
+		return
+	}
+	if model.Compose.Config.IsNull() {
+		model.Compose.Config = types.StringValue(instance.ConfigYML)
+	}
+
+	instancePath := path.Root("compose").AtName("instance")
+	for i, instanceModel := range model.Compose.Instance {
+		if id := instanceModel.ID; !id.IsUnknown() && !composeInstanceIDRegex.MatchString(id.ValueString()) {
+			resp.Diagnostics.AddAttributeError(instancePath.AtListIndex(i).AtName("id"), "Invalid AEM instance identifier", fmt.Sprintf("Identifier '%s' may contain only letters, digits, underscores and hyphens.", id.ValueString()))
+		}
+		if httpURL := instanceModel.HTTPURL; !httpURL.IsUnknown() {
+			if err := validateConfigURL(httpURL.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(instancePath.AtListIndex(i).AtName("http_url"), "Invalid AEM instance URL", fmt.Sprintf("Value %s.", err))
+			}
+		}
+	}
+	if resp.Diagnostics.HasError() || !composeConfigKnown(model) {
+		return
+	}
+
+	configPath := path.Root("compose").AtName("config")
+	if !model.Compose.Overrides.IsNull() {
+		configPath = path.Root("compose").AtName("overrides")
+	}
+	rendered, err := renderComposeConfig(ctx, model)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(configPath, "Invalid AEM Compose configuration", fmt.Sprintf("%s", err))
+		return
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(rendered), &doc); err != nil {
+		resp.Diagnostics.AddAttributeError(configPath, "Invalid AEM Compose configuration", fmt.Sprintf("cannot parse YAML: %s", err))
+		return
+	}
+	if !model.Compose.Passwords.IsUnknown() {
+		var passwords map[string]types.String
+		model.Compose.Passwords.ElementsAs(ctx, &passwords, true)
+		instances := composeInstancesNode(&doc)
+		for id := range passwords {
+			if instances == nil || configMappingValue(instances, id) == nil {
+				resp.Diagnostics.AddAttributeError(path.Root("compose").AtName("passwords").AtMapKey(id), "Unknown AEM instance", fmt.Sprintf("Password is set for AEM instance '%s' which is not defined in the configuration.", id))
+			}
+		}
+	}
+	for _, issue := range validateConfigNode(configDocumentRoot(&doc), composeConfigRule, "") {
+		summary := "Invalid AEM Compose configuration"
+		detail := fmt.Sprintf("Key '%s' %s.", issue.key, issue.message)
+		if issue.warning {
+			resp.Diagnostics.AddAttributeWarning(configPath, "Unknown AEM Compose configuration key", detail)
+		} else {
+			resp.Diagnostics.AddAttributeError(configPath, summary, detail)
+		}
+	}
+}
+
+func validateConfigNode(node *yaml.Node, rule configRule, key string) []configIssue {
+	var issues []configIssue
+	if rule.kind == configAny || isTemplatedConfigValue(node) || node.Tag == "!!null" {
+		return nil
+	}
+	switch rule.kind {
+	case configMapping:
+		if node.Kind != yaml.MappingNode {
+			return []configIssue{{key: key, message: "needs to be a mapping"}}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name := node.Content[i].Value
+			childKey := strings.TrimPrefix(key+"."+name, ".")
+			if rule.values != nil {
+				if !isTemplatedConfigValue(node.Content[i]) && !composeInstanceIDRegex.MatchString(name) {
+					issues = append(issues, configIssue{key: childKey, message: "is not a valid identifier (only letters, digits, underscores and hyphens are allowed)"})
+				}
+				issues = append(issues, validateConfigNode(node.Content[i+1], *rule.values, childKey)...)
+				continue
+			}
+			childRule, known := rule.fields[name]
+			if !known {
+				issues = append(issues, configIssue{key: childKey, message: "is not known", warning: true})
+				continue
+			}
+			issues = append(issues, validateConfigNode(node.Content[i+1], childRule, childKey)...)
+		}
+		return issues
+	case configList:
+		if node.Kind != yaml.SequenceNode {
+			return []configIssue{{key: key, message: "needs to be a list"}}
+		}
+		return nil
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return []configIssue{{key: key, message: "needs to be a scalar value"}}
+	}
+	value := node.Value
+	switch rule.kind {
+	case configBool:
+		if node.Tag != "!!bool" {
+			return []configIssue{{key: key, message: fmt.Sprintf("needs to be a boolean, got '%s'", value)}}
+		}
+	case configInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return []configIssue{{key: key, message: fmt.Sprintf("needs to be an integer, got '%s'", value)}}
+		}
+	case configDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return []configIssue{{key: key, message: fmt.Sprintf("needs to be a duration like '30s' or '10m', got '%s'", value)}}
+		}
+	case configURL:
+		if err := validateConfigURL(value); err != nil {
+			return []configIssue{{key: key, message: err.Error()}}
+		}
+	case configEnum:
+		if !slices.Contains(rule.enum, value) {
+			return []configIssue{{key: key, message: fmt.Sprintf("needs to be one of '%s', got '%s'", strings.Join(rule.enum, "', '"), value)}}
+		}
+	}
+	return nil
+}
+
+func validateConfigURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("needs to be an HTTP(S) URL like 'http://127.0.0.1:4502', got '%s'", value)
+	}
+	return nil
+}
+
+// isTemplatedConfigValue checks if the value is rendered by AEM Compose, so it cannot be validated before that.
+func isTemplatedConfigValue(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "[[")
+}
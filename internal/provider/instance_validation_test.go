@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateConfigNode(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []configIssue
+	}{
+		{
+			name:   "valid config",
+			config: "instance:\n  config:\n    local_author:\n      active: true\n      http_url: http://127.0.0.1:4502\n      run_modes: [local]\n  check:\n    interval: 5s\n    done_threshold: 3\nlog:\n  level: info\n",
+			want:   nil,
+		},
+		{
+			name:   "empty config",
+			config: "",
+			want:   nil,
+		},
+		{
+			name:   "templated and null values skipped",
+			config: "instance:\n  config:\n    local_author:\n      http_url: '[[.Env.AEM_AUTHOR_URL]]'\n      active: ~\n",
+			want:   nil,
+		},
+		{
+			name:   "unchecked sections accept anything",
+			config: "java:\n  home_dir: /opt/java\n  anything: [1, 2]\n",
+			want:   nil,
+		},
+		{
+			name:   "unknown key reported as warning",
+			config: "log:\n  colors: true\n",
+			want:   []configIssue{{key: "log.colors", message: "is not known", warning: true}},
+		},
+		{
+			name:   "invalid scalar values",
+			config: "instance:\n  processing_mode: random\n  check:\n    interval: soon\n    done_threshold: many\n    await_strict: 'yes'\n",
+			want: []configIssue{
+				{key: "instance.processing_mode", message: "needs to be one of 'auto', 'parallel', 'serial', got 'random'"},
+				{key: "instance.check.interval", message: "needs to be a duration like '30s' or '10m', got 'soon'"},
+				{key: "instance.check.done_threshold", message: "needs to be an integer, got 'many'"},
+				{key: "instance.check.await_strict", message: "needs to be a boolean, got 'yes'"},
+			},
+		},
+		{
+			name:   "invalid instance",
+			config: "instance:\n  config:\n    local author:\n      http_url: 127.0.0.1:4502\n      jvm_opts: -Xmx4g\n",
+			want: []configIssue{
+				{key: "instance.config.local author", message: "is not a valid identifier (only letters, digits, underscores and hyphens are allowed)"},
+				{key: "instance.config.local author.http_url", message: "needs to be an HTTP(S) URL like 'http://127.0.0.1:4502', got '127.0.0.1:4502'"},
+				{key: "instance.config.local author.jvm_opts", message: "needs to be a list"},
+			},
+		},
+		{
+			name:   "wrong structure",
+			config: "instance: enabled\nlog:\n  level: [debug]\n",
+			want: []configIssue{
+				{key: "instance", message: "needs to be a mapping"},
+				{key: "log.level", message: "needs to be a scalar value"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.config), &doc); err != nil {
+				t.Fatal(err)
+			}
+			got := validateConfigNode(configDocumentRoot(&doc), composeConfigRule, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateConfigNode() =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
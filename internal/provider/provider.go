@@ -3,11 +3,16 @@ package provider
 import (
 	"context"
 	_ "embed"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/wttech/terraform-provider-aem/internal/client"
+	"github.com/wttech/terraform-provider-aem/internal/provider/instance"
 )
 
 // Ensure AEMProvider satisfies various provider interfaces.
@@ -24,7 +29,83 @@ type AEMProvider struct {
 	version string
 }
 
-type AEMProviderModel struct{}
+type AEMProviderModel struct {
+	Client  *ProviderClientModel  `tfsdk:"client"`
+	System  *ProviderSystemModel  `tfsdk:"system"`
+	Compose *ProviderComposeModel `tfsdk:"compose"`
+	Hosts   types.Map             `tfsdk:"hosts"`
+
+	AWSAPIRateLimit types.Float64 `tfsdk:"aws_api_rate_limit"`
+	AWSAPIBurst     types.Int64   `tfsdk:"aws_api_burst"`
+}
+
+type ProviderHostModel struct {
+	Client struct {
+		Type        types.String `tfsdk:"type"`
+		Settings    types.Map    `tfsdk:"settings"`
+		Credentials types.Map    `tfsdk:"credentials"`
+	} `tfsdk:"client"`
+	Facts types.Map `tfsdk:"facts"`
+}
+
+type ProviderClientModel struct {
+	Type          types.String `tfsdk:"type"`
+	Settings      types.Map    `tfsdk:"settings"`
+	Credentials   types.Map    `tfsdk:"credentials"`
+	ActionTimeout types.String `tfsdk:"action_timeout"`
+	StateTimeout  types.String `tfsdk:"state_timeout"`
+}
+
+type ProviderSystemModel struct {
+	DataDir types.String `tfsdk:"data_dir"`
+	WorkDir types.String `tfsdk:"work_dir"`
+}
+
+type ProviderComposeModel struct {
+	Version types.String `tfsdk:"version"`
+}
+
+func stringMap(ctx context.Context, value types.Map) (map[string]string, diag.Diagnostics) {
+	result := map[string]string{}
+	if value.IsNull() {
+		return result, nil
+	}
+	diags := value.ElementsAs(ctx, &result, false)
+	return result, diags
+}
+
+// mapKnown checks if the map and all its elements are known.
+func mapKnown(value types.Map) bool {
+	if value.IsUnknown() {
+		return false
+	}
+	for _, element := range value.Elements() {
+		if element.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
+
+// normalized treats blocks not defined in the configuration as having all attributes unset.
+func (m AEMProviderModel) normalized() AEMProviderModel {
+	if m.Client == nil {
+		m.Client = &ProviderClientModel{}
+	}
+	if m.System == nil {
+		m.System = &ProviderSystemModel{}
+	}
+	if m.Compose == nil {
+		m.Compose = &ProviderComposeModel{}
+	}
+	return m
+}
+
+// ProviderData is passed to resources and data sources once the provider is configured.
+type ProviderData struct {
+	ClientManager *client.ClientManager
+	Defaults      AEMProviderModel
+}
 
 func (p *AEMProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "aem"
@@ -34,7 +115,102 @@ func (p *AEMProvider) Metadata(ctx context.Context, req provider.MetadataRequest
 func (p *AEMProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: DescriptionMD,
-		Attributes:          map[string]schema.Attribute{},
+		Attributes: map[string]schema.Attribute{
+			"aws_api_rate_limit": schema.Float64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum rate (requests per second) of AWS API calls made by 'aws-ssm' and 'aws-eic' connections. Shared by all connections using the same region and credential configuration (profile, access key and assumed roles) to avoid throttling when many instances are managed at once. Set to 0 to disable. Defaults to '%v'.", client.AWSAPIRateLimitDefault),
+				Optional:            true,
+			},
+			"aws_api_burst": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of AWS API calls made at once before the rate limit applies. Defaults to twice the rate limit.",
+				Optional:            true,
+			},
+			"hosts": schema.MapNestedAttribute{
+				MarkdownDescription: "Named inventory of machines. AEM instance resources may refer to them by name using the 'host' attribute instead of repeating the connection settings.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"client": schema.SingleNestedAttribute{
+							MarkdownDescription: "Connection settings used to access the machine.",
+							Required:            true,
+							Attributes: map[string]schema.Attribute{
+								"type": schema.StringAttribute{
+									MarkdownDescription: "Type of connection to use to connect to the machine.",
+									Required:            true,
+								},
+								"settings": schema.MapAttribute{
+									MarkdownDescription: "Settings for the connection type",
+									ElementType:         types.StringType,
+									Optional:            true,
+								},
+								"credentials": schema.MapAttribute{
+									MarkdownDescription: "Credentials for the connection type",
+									ElementType:         types.StringType,
+									Optional:            true,
+									Sensitive:           true,
+								},
+							},
+						},
+						"facts": schema.MapAttribute{
+							MarkdownDescription: "Arbitrary facts about the machine (e.g. role, environment) exposed by resources referring to it.",
+							ElementType:         types.StringType,
+							Optional:            true,
+						},
+					},
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"client": schema.SingleNestedBlock{
+				MarkdownDescription: "Default connection settings inherited by all AEM instance resources. Settings and credentials are merged with the ones defined on the resource level (the latter take precedence).",
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						MarkdownDescription: "Default type of connection to use to connect to the machine on which AEM instance will be running.",
+						Optional:            true,
+					},
+					"settings": schema.MapAttribute{
+						MarkdownDescription: "Default settings for the connection type",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"credentials": schema.MapAttribute{
+						MarkdownDescription: "Default credentials for the connection type",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Sensitive:           true,
+					},
+					"action_timeout": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Default timeout used when trying to connect to the AEM instance machine. Defaults to '%s'.", instance.ActionTimeoutDefault),
+						Optional:            true,
+					},
+					"state_timeout": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Default timeout used when reading the AEM instance state when determining the plan. Defaults to '%s'.", instance.StateTimeoutDefault),
+						Optional:            true,
+					},
+				},
+			},
+			"system": schema.SingleNestedBlock{
+				MarkdownDescription: "Default operating system configuration inherited by all AEM instance resources.",
+				Attributes: map[string]schema.Attribute{
+					"data_dir": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Default remote root path in which AEM Compose files and unpacked AEM instances will be stored. Defaults to '%s'.", instance.DataDirDefault),
+						Optional:            true,
+					},
+					"work_dir": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Default remote root path where provider-related files will be stored. Defaults to '%s'.", instance.WorkDirDefault),
+						Optional:            true,
+					},
+				},
+			},
+			"compose": schema.SingleNestedBlock{
+				MarkdownDescription: "Default AEM Compose CLI configuration inherited by all AEM instance resources.",
+				Attributes: map[string]schema.Attribute{
+					"version": schema.StringAttribute{
+						MarkdownDescription: fmt.Sprintf("Default version of AEM Compose tool to use on remote machine. Defaults to '%s'.", instance.ComposeVersionDefault),
+						Optional:            true,
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -45,9 +221,65 @@ func (p *AEMProvider) Configure(ctx context.Context, req provider.ConfigureReque
 		return
 	}
 
-	clientManager := client.ClientManagerDefault
-	resp.DataSourceData = clientManager
-	resp.ResourceData = clientManager
+	clientManager := client.NewClientManager()
+	rateLimit := client.AWSAPIRateLimitDefault
+	if !data.AWSAPIRateLimit.IsNull() && !data.AWSAPIRateLimit.IsUnknown() {
+		rateLimit = data.AWSAPIRateLimit.ValueFloat64()
+	}
+	clientManager.SetAWSAPIRateLimit(rateLimit, int(data.AWSAPIBurst.ValueInt64()))
+	resp.Diagnostics.Append(p.registerHosts(ctx, clientManager, data.Hosts)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	providerData := &ProviderData{
+		ClientManager: clientManager,
+		Defaults:      data.normalized(),
+	}
+	resp.DataSourceData = providerData
+	resp.ResourceData = providerData
+}
+
+// registerHosts adds the inventory to the client manager; hosts not known yet (e.g. referring to machines being created) are marked as such,
+// so that resources plan their connection as unknown instead of failing.
+func (p *AEMProvider) registerHosts(ctx context.Context, clientManager *client.ClientManager, hosts types.Map) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if hosts.IsUnknown() {
+		clientManager.SetHostsUnknown()
+		return diags
+	}
+	for name, value := range hosts.Elements() {
+		object, ok := value.(types.Object)
+		if !ok || object.IsUnknown() {
+			clientManager.RegisterHost(name, client.Host{Unknown: true})
+			continue
+		}
+		var host ProviderHostModel
+		diags.Append(object.As(ctx, &host, basetypes.ObjectAsOptions{})...)
+		if diags.HasError() {
+			return diags
+		}
+		if host.Client.Type.IsUnknown() || !mapKnown(host.Client.Settings) || !mapKnown(host.Client.Credentials) || !mapKnown(host.Facts) {
+			clientManager.RegisterHost(name, client.Host{Unknown: true})
+			continue
+		}
+		settings, settingsDiags := stringMap(ctx, host.Client.Settings)
+		diags.Append(settingsDiags...)
+		credentials, credentialsDiags := stringMap(ctx, host.Client.Credentials)
+		diags.Append(credentialsDiags...)
+		facts, factsDiags := stringMap(ctx, host.Facts)
+		diags.Append(factsDiags...)
+		if diags.HasError() {
+			return diags
+		}
+		clientManager.RegisterHost(name, client.Host{
+			TypeName:    host.Client.Type.ValueString(),
+			Settings:    settings,
+			Credentials: credentials,
+			Facts:       facts,
+		})
+	}
+	return diags
 }
 
 func (p *AEMProvider) Resources(ctx context.Context) []func() resource.Resource {
@@ -55,7 +287,7 @@ func (p *AEMProvider) Resources(ctx context.Context) []func() resource.Resource
 }
 
 func (p *AEMProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{NewInstanceStatusDataSource}
 }
 
 func New(version string) func() provider.Provider {
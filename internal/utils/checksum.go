@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func FileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file '%s': %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("cannot read file '%s': %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ChecksumsFind looks up the checksum of the file in the content of a checksums file in the 'sha256sum' format.
+func ChecksumsFind(checksums string, fileName string) (string, bool) {
+	for _, line := range strings.Split(checksums, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == fileName {
+			return fields[0], true
+		}
+	}
+	return "", false
+}
+
+// PathChecksum calculates the SHA-256 checksum of a file or, for a directory, of the relative paths and contents of all files inside it.
+func PathChecksum(path string) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot stat path '%s': %w", path, err)
+	}
+	if !stat.IsDir() {
+		return FileChecksum(path)
+	}
+	hash := sha256.New()
+	err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		fileChecksum, err := FileChecksum(filePath)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(hash, "%s %s\n", fileChecksum, filepath.ToSlash(relPath))
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot calculate checksum of directory '%s': %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
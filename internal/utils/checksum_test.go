@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPathChecksum(t *testing.T) {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	write := func(t *testing.T, path string, content string, modTime time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prepare := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, "a.txt"), "alpha", modTime)
+		write(t, filepath.Join(dir, "sub", "b.txt"), "beta", modTime)
+		return dir
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		change  func(t *testing.T, dir string)
+		changed bool
+	}{
+		{
+			name:    "file unchanged",
+			path:    "a.txt",
+			change:  func(t *testing.T, dir string) {},
+			changed: false,
+		},
+		{
+			name: "file touched",
+			path: "a.txt",
+			change: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, "a.txt"), "alpha", modTime.Add(time.Hour))
+			},
+			changed: false,
+		},
+		{
+			name: "file content changed with same size and modification time",
+			path: "a.txt",
+			change: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, "a.txt"), "omega", modTime)
+			},
+			changed: true,
+		},
+		{
+			name: "file resized",
+			path: "a.txt",
+			change: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, "a.txt"), "alphabet", modTime)
+			},
+			changed: true,
+		},
+		{
+			name:    "directory unchanged",
+			path:    ".",
+			change:  func(t *testing.T, dir string) {},
+			changed: false,
+		},
+		{
+			name: "directory with nested file touched",
+			path: ".",
+			change: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, "sub", "b.txt"), "beta", modTime.Add(time.Hour))
+			},
+			changed: false,
+		},
+		{
+			name: "directory with nested file content changed",
+			path: ".",
+			change: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, "sub", "b.txt"), "bet4", modTime)
+			},
+			changed: true,
+		},
+		{
+			name: "directory with file added",
+			path: ".",
+			change: func(t *testing.T, dir string) {
+				write(t, filepath.Join(dir, "c.txt"), "gamma", modTime)
+			},
+			changed: true,
+		},
+		{
+			name: "directory with file renamed",
+			path: ".",
+			change: func(t *testing.T, dir string) {
+				if err := os.Rename(filepath.Join(dir, "a.txt"), filepath.Join(dir, "z.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			changed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := prepare(t)
+			path := filepath.Join(dir, tt.path)
+			before, err := PathChecksum(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			tt.change(t, dir)
+			after, err := PathChecksum(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if (before != after) != tt.changed {
+				t.Errorf("checksum changed = %t, want %t", before != after, tt.changed)
+			}
+		})
+	}
+
+	t.Run("missing path", func(t *testing.T) {
+		if _, err := PathChecksum(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("expected error for missing path")
+		}
+	})
+}
+
+func TestChecksumsFind(t *testing.T) {
+	checksums := "aaa111  aemc-cli_linux_amd64.tar.gz\nbbb222 *aemc-cli_linux_arm64.tar.gz\n\nmalformed line here\n"
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+		found    bool
+	}{
+		{name: "text mode entry", fileName: "aemc-cli_linux_amd64.tar.gz", want: "aaa111", found: true},
+		{name: "binary mode entry", fileName: "aemc-cli_linux_arm64.tar.gz", want: "bbb222", found: true},
+		{name: "missing entry", fileName: "aemc-cli_darwin_arm64.tar.gz", want: "", found: false},
+		{name: "partial name", fileName: "aemc-cli_linux_amd64", want: "", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := ChecksumsFind(checksums, tt.fileName)
+			if got != tt.want || found != tt.found {
+				t.Errorf("ChecksumsFind() = (%q, %t), want (%q, %t)", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
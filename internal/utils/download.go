@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DownloadTimeout limits the whole download including reading the body, so that a stalled server does not hang the apply.
+const DownloadTimeout = 30 * time.Minute
+
+var downloadClient = &http.Client{Timeout: DownloadTimeout}
+
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// URLFileName returns the last path segment of the URL (without query) or the base name of the local path.
+func URLFileName(source string) string {
+	if IsURL(source) {
+		if parsed, err := url.Parse(source); err == nil {
+			return path.Base(parsed.Path)
+		}
+	}
+	return filepath.Base(source)
+}
+
+// DownloadCached downloads the file to the cache directory unless it is already cached there and returns its local path.
+func DownloadCached(ctx context.Context, fileURL string, cacheDir string) (string, error) {
+	hash := sha256.Sum256([]byte(fileURL))
+	localPath := filepath.Join(cacheDir, fmt.Sprintf("%s-%s", hex.EncodeToString(hash[:8]), URLFileName(fileURL)))
+	if _, err := os.Stat(localPath); err == nil {
+		return localPath, nil
+	}
+	if err := DownloadFile(ctx, fileURL, localPath); err != nil {
+		return "", err
+	}
+	return localPath, nil
+}
+
+func DownloadFile(ctx context.Context, fileURL string, localPath string) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("cannot create directory for file '%s': %w", localPath, err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return fmt.Errorf("cannot prepare download of file '%s': %w", fileURL, err)
+	}
+	response, err := downloadClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("cannot download file '%s': %w", fileURL, err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot download file '%s': unexpected status '%s'", fileURL, response.Status)
+	}
+	file, err := os.CreateTemp(filepath.Dir(localPath), filepath.Base(localPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file for download '%s': %w", fileURL, err)
+	}
+	tmpPath := file.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if _, err := io.Copy(file, response.Body); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot save downloaded file '%s': %w", fileURL, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("cannot save downloaded file '%s': %w", fileURL, err)
+	}
+	if err := os.Rename(tmpPath, localPath); err != nil {
+		return fmt.Errorf("cannot move downloaded file to '%s': %w", localPath, err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -15,3 +16,21 @@ func EnvToScript(env map[string]string) string {
 	}
 	return sb.String()
 }
+
+// EnvToFile formats the environment variables to be loaded both by systemd ('EnvironmentFile') and by shell scripts.
+func EnvToFile(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		escapedValue := env[name]
+		for _, char := range []string{"\\", "\"", "$", "`"} {
+			escapedValue = strings.ReplaceAll(escapedValue, char, "\\"+char)
+		}
+		sb.WriteString(fmt.Sprintf("%s=\"%s\"\n", name, escapedValue))
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvToFile(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "empty",
+			env:  map[string]string{},
+			want: "",
+		},
+		{
+			name: "sorted by name",
+			env:  map[string]string{"B": "2", "A": "1"},
+			want: "A=\"1\"\nB=\"2\"\n",
+		},
+		{
+			name: "special characters escaped",
+			env:  map[string]string{"SECRET": `p"a$s\w` + "`rd`"},
+			want: "SECRET=\"p\\\"a\\$s\\\\w\\`rd\\`\"\n",
+		},
+		{
+			name: "single quotes and spaces kept",
+			env:  map[string]string{"JAVA_OPTS": "-Xmx4g -Dname='a b'"},
+			want: "JAVA_OPTS=\"-Xmx4g -Dname='a b'\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EnvToFile(tt.env); got != tt.want {
+				t.Errorf("EnvToFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvToFileSourcedByShell(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("shell not available")
+	}
+	values := []string{
+		`plain`,
+		`with "double" quotes`,
+		`with 'single' quotes`,
+		`$HOME and ${PATH} and $(id) and ` + "`id`",
+		`back\slash\\es\`,
+		`semi;colon && pipe | amp &`,
+	}
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secret.env")
+			if err := os.WriteFile(path, []byte(EnvToFile(map[string]string{"VALUE": value})), 0600); err != nil {
+				t.Fatal(err)
+			}
+			out, err := exec.Command(shell, "-c", `. "$1" && printf '%s' "$VALUE"`, "sh", path).Output()
+			if err != nil {
+				t.Fatalf("cannot source env file: %s", err)
+			}
+			if string(out) != value {
+				t.Errorf("sourced value = %q, want %q", string(out), value)
+			}
+		})
+	}
+}